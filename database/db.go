@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -11,13 +12,14 @@ import (
 	// source/file import is required for migration files to read
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
-
-	// load pq as database driver
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var (
 	Todo *sqlx.DB
+
+	// connStr is kept for connections that cannot come from the pool of Todo, like LISTEN.
+	connStr string
 )
 
 type SSLMode string
@@ -28,7 +30,7 @@ const (
 )
 
 func CreateAndMigrate(host, port, user, password, dbname string, sslmode SSLMode) error {
-	connStr := fmt.Sprintf("host =%s port = %s user =%s password =%s dbname =%s sslmode=%s ", host, port, user, password, dbname, sslmode)
+	connStr = fmt.Sprintf("host =%s port = %s user =%s password =%s dbname =%s sslmode=%s ", host, port, user, password, dbname, sslmode)
 	DataBase, err := sqlx.Open("postgres", connStr)
 	if err != nil {
 		return err
@@ -40,6 +42,13 @@ func CreateAndMigrate(host, port, user, password, dbname string, sslmode SSLMode
 	Todo = DataBase
 	return migrateUp(DataBase)
 }
+
+// NewListener opens a dedicated connection for LISTEN, reconnecting after
+// failures with a growing delay.
+func NewListener(eventCallback pq.EventCallbackType) *pq.Listener {
+	return pq.NewListener(connStr, time.Second, time.Minute, eventCallback)
+}
+
 func migrateUp(db *sqlx.DB) error {
 	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
 	if err != nil {
@@ -0,0 +1,297 @@
+package dbhelper
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// accountExportColumns is the column list selected by every query returning model.AccountExport.
+const accountExportColumns = `id, status, size, created_at, finished_at, expires_at`
+
+func CreateAccountExport(userID string) (model.AccountExport, error) {
+	query := `
+		INSERT INTO account_exports (user_id)
+		VALUES ($1)
+		RETURNING ` + accountExportColumns
+
+	var export model.AccountExport
+	err := database.Todo.Get(&export, query, userID)
+	return export, err
+}
+
+// GetPendingAccountExport returns the export of the user that is still being
+// built, if any.
+func GetPendingAccountExport(userID string) (*model.AccountExport, error) {
+	var export model.AccountExport
+
+	query := `
+		SELECT ` + accountExportColumns + `
+		FROM account_exports
+		WHERE user_id = $1
+		  AND status = 'pending'
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	err := database.Todo.Get(&export, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// GetAccountExport returns an export of the user that has not expired.
+func GetAccountExport(userID, exportID string) (*model.AccountExport, error) {
+	var export model.AccountExport
+
+	query := `
+		SELECT ` + accountExportColumns + `
+		FROM account_exports
+		WHERE id = $1
+		  AND user_id = $2
+		  AND (expires_at IS NULL OR expires_at > NOW())
+	`
+
+	err := database.Todo.Get(&export, query, exportID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// GetAccountExportArchive returns the ZIP of a ready export of the user.
+func GetAccountExportArchive(userID, exportID string) ([]byte, error) {
+	query := `
+		SELECT archive
+		FROM account_exports
+		WHERE id = $1
+		  AND user_id = $2
+		  AND status = 'ready'
+	`
+
+	var archive []byte
+	err := database.Todo.Get(&archive, query, exportID, userID)
+	return archive, err
+}
+
+// ClaimAccountExport leases the oldest export waiting to be built, skipping the
+// ones leased by other replicas. The export can then be built outside of any
+// transaction; should it never be finished, it is taken again once the lease
+// is over.
+func ClaimAccountExport(lease time.Duration) (*model.PendingAccountExport, error) {
+	var export model.PendingAccountExport
+
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM account_exports
+			WHERE status = 'pending'
+			  AND (leased_until IS NULL OR leased_until < NOW())
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE account_exports e
+		SET leased_until = NOW() + make_interval(secs => $1)
+		FROM due
+		WHERE e.id = due.id
+		RETURNING e.id, e.user_id
+	`
+
+	err := database.Todo.Get(&export, query, lease.Seconds())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// FinishAccountExport stores the outcome of building an export, with its ZIP
+// when it is ready. Either way the export is kept until expiresAt.
+func FinishAccountExport(exportID, status string, archive []byte, expiresAt time.Time) error {
+	query := `
+		UPDATE account_exports
+		SET status = $2,
+		    archive = CASE WHEN $2 = 'ready' THEN $3::bytea END,
+		    size = CASE WHEN $2 = 'ready' THEN LENGTH($3::bytea) END,
+		    finished_at = NOW(),
+		    expires_at = $4,
+		    leased_until = NULL
+		WHERE id = $1
+	`
+	_, err := database.Todo.Exec(query, exportID, status, archive, expiresAt)
+	return err
+}
+
+// PurgeExpiredAccountExports deletes the exports past their expiry and returns how many were removed.
+func PurgeExpiredAccountExports() (int64, error) {
+	query := `DELETE FROM account_exports WHERE expires_at < NOW()`
+
+	result, err := database.Todo.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func GetAccountProfile(userID string) (model.AccountProfile, error) {
+	query := `
+		SELECT id, name, email, created_at, updated_at
+		FROM users
+		WHERE id = $1
+	`
+
+	var profile model.AccountProfile
+	err := database.Todo.Get(&profile, query, userID)
+	return profile, err
+}
+
+// GetAccountTodos returns every todo of the user, deleted ones included, with
+// their checklists, oldest first.
+func GetAccountTodos(userID string) ([]model.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+
+	todos := []model.Todo{}
+	if err := database.Todo.Select(&todos, query, userID); err != nil {
+		return nil, err
+	}
+
+	queryItems := `
+		SELECT i.todo_id, i.id, i.title, i.done, i.position, i.created_at
+		FROM todo_items i
+		JOIN todos t ON t.id = i.todo_id
+		WHERE t.user_id = $1
+		ORDER BY i.position, i.created_at
+	`
+
+	var items []struct {
+		TodoID string `db:"todo_id"`
+		model.TodoItem
+	}
+	if err := database.Todo.Select(&items, queryItems, userID); err != nil {
+		return nil, err
+	}
+
+	byTodo := make(map[string][]model.TodoItem, len(todos))
+	for _, item := range items {
+		byTodo[item.TodoID] = append(byTodo[item.TodoID], item.TodoItem)
+	}
+	for i := range todos {
+		todos[i].Items = byTodo[todos[i].ID]
+	}
+	return todos, nil
+}
+
+// GetAccountProjects returns every project of the user, archived ones included.
+func GetAccountProjects(userID string) ([]model.Project, error) {
+	query := `
+		SELECT p.id, p.name, p.description, p.created_at, p.archived_at,
+		       (SELECT COUNT(*) FROM todos t WHERE t.project_id = p.id AND t.archived_at IS NULL) AS todo_count
+		FROM projects p
+		WHERE p.user_id = $1
+		ORDER BY p.created_at
+	`
+
+	projects := []model.Project{}
+	err := database.Todo.Select(&projects, query, userID)
+	return projects, err
+}
+
+// GetUserSessions returns every session of the user, ended ones included.
+func GetUserSessions(userID string) ([]model.UserSession, error) {
+	query := `
+		SELECT session_id, expires_at, archived_at
+		FROM user_sessions
+		WHERE user_id = $1
+		ORDER BY expires_at
+	`
+
+	sessions := []model.UserSession{}
+	err := database.Todo.Select(&sessions, query, userID)
+	return sessions, err
+}
+
+// GetUserHistory returns the audit trail of every todo of the user, oldest first.
+func GetUserHistory(userID string) ([]model.TodoHistory, error) {
+	query := `
+		SELECT id, todo_id, session_id, action, changes, created_at
+		FROM todo_history
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`
+
+	history := []model.TodoHistory{}
+	err := database.Todo.Select(&history, query, userID)
+	return history, err
+}
+
+// GetAccountReminders returns the reminders of every todo of the user, deleted
+// todos included.
+func GetAccountReminders(userID string) ([]model.AccountReminder, error) {
+	query := `
+		SELECT r.todo_id, r.id, r.offset_minutes,
+		       t.deadline - r.offset_minutes * INTERVAL '1 minute' AS remind_at,
+		       COALESCE(r.done_for = t.deadline, FALSE) AS done,
+		       r.done_at, r.created_at
+		FROM todo_reminders r
+		JOIN todos t ON t.id = r.todo_id
+		WHERE t.user_id = $1
+		ORDER BY r.created_at, r.id
+	`
+
+	reminders := []model.AccountReminder{}
+	err := database.Todo.Select(&reminders, query, userID)
+	return reminders, err
+}
+
+// GetAccountWebhookDeliveries returns the deliveries of every webhook of the user, oldest first.
+func GetAccountWebhookDeliveries(userID string) ([]model.AccountWebhookDelivery, error) {
+	query := `
+		SELECT d.webhook_id, d.id, d.event_id, e.event, d.status, d.attempts, d.response_status, d.error,
+		       CASE WHEN d.status = 'pending' THEN d.next_attempt_at END AS next_attempt_at,
+		       d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		JOIN outbox_events e ON e.id = d.event_id
+		WHERE w.user_id = $1
+		ORDER BY d.id
+	`
+
+	deliveries := []model.AccountWebhookDelivery{}
+	err := database.Todo.Select(&deliveries, query, userID)
+	return deliveries, err
+}
+
+// GetAccountCalendarTokens returns every calendar token of the user, revoked
+// ones included, without their hashes.
+func GetAccountCalendarTokens(userID string) ([]model.AccountCalendarToken, error) {
+	query := `
+		SELECT id, created_at, last_used_at, revoked_at
+		FROM calendar_tokens
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	tokens := []model.AccountCalendarToken{}
+	err := database.Todo.Select(&tokens, query, userID)
+	return tokens, err
+}
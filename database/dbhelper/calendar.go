@@ -0,0 +1,106 @@
+package dbhelper
+
+import (
+	"database/sql"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+)
+
+// GetCalendarToken returns the calendar token in use by the user, if any.
+func GetCalendarToken(userID string) (*model.CalendarToken, error) {
+	var token model.CalendarToken
+
+	query := `
+		SELECT id, created_at, last_used_at
+		FROM calendar_tokens
+		WHERE user_id = $1
+		  AND revoked_at IS NULL
+	`
+
+	err := database.Todo.Get(&token, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RotateCalendarToken revokes the calendar token of the user, if any, and
+// stores the hash of its replacement.
+func RotateCalendarToken(tx *sqlx.Tx, userID, tokenHash string) (model.CalendarToken, error) {
+	if _, err := RevokeCalendarToken(tx, userID); err != nil {
+		return model.CalendarToken{}, err
+	}
+
+	query := `
+		INSERT INTO calendar_tokens (user_id, token_hash)
+		VALUES ($1, $2)
+		RETURNING id, created_at, last_used_at
+	`
+
+	var token model.CalendarToken
+	err := tx.Get(&token, query, userID, tokenHash)
+	return token, err
+}
+
+// RevokeCalendarToken revokes the calendar token of the user and reports
+// whether there was one.
+func RevokeCalendarToken(tx *sqlx.Tx, userID string) (bool, error) {
+	query := `
+		UPDATE calendar_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1
+		  AND revoked_at IS NULL
+	`
+
+	result, err := tx.Exec(query, userID)
+	if err != nil {
+		return false, err
+	}
+	revoked, err := result.RowsAffected()
+	return revoked > 0, err
+}
+
+// UseCalendarToken returns the user of the calendar token with tokenHash and
+// records its use, or "" when no active user has such a token in use.
+func UseCalendarToken(tokenHash string) (string, error) {
+	query := `
+		UPDATE calendar_tokens ct
+		SET last_used_at = NOW()
+		FROM users u
+		WHERE ct.token_hash = $1
+		  AND ct.revoked_at IS NULL
+		  AND u.id = ct.user_id
+		  AND u.archived_at IS NULL
+		RETURNING ct.user_id
+	`
+
+	var userID string
+	err := database.Todo.Get(&userID, query, tokenHash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return userID, err
+}
+
+// GetCalendarTodos returns the todos of the user that have a deadline,
+// leaving the ones in the trash out.
+func GetCalendarTodos(userID string) ([]model.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE user_id = $1
+		  AND archived_at IS NULL
+		  AND deadline IS NOT NULL
+		ORDER BY deadline, id
+	`
+
+	todos := []model.Todo{}
+	err := database.Todo.Select(&todos, query, userID)
+	return todos, err
+}
@@ -2,33 +2,110 @@ package dbhelper
 
 import (
 	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Shubhouy1/todo-app/database"
 	"github.com/Shubhouy1/todo-app/model"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-func CreateTodo(userId, title, status, description string, deadline time.Time) error {
+// todoTagsColumn selects the tag names of the todo in the current row.
+const todoTagsColumn = `ARRAY(
+			SELECT tg.name
+			FROM todo_tags tt
+			JOIN tags tg ON tg.id = tt.tag_id
+			WHERE tt.todo_id = todos.id
+			ORDER BY LOWER(tg.name)
+		) AS tags`
+
+// todoProgressColumn summarises the checklist of the todo in the current row as "3/7 done".
+const todoProgressColumn = `COALESCE((
+			SELECT COUNT(*) FILTER (WHERE i.done) || '/' || COUNT(*) || ' done'
+			FROM todo_items i
+			WHERE i.todo_id = todos.id
+			HAVING COUNT(*) > 0
+		), '') AS progress`
+
+// todoColumns is the column list selected by every query returning model.Todo.
+const todoColumns = `id, title, status, description, deadline, created_at, project_id, position, auto_complete,
+		       recurrence, series_id, priority, archived_at, version, completed_at, completed_late,
+		       ` + todoTagsColumn + `,
+		       ` + todoProgressColumn
+
+func CreateTodo(tx *sqlx.Tx, userId string, todo model.Todo) (string, error) {
 	query := `
-		INSERT INTO todos (user_id, title, status,description,deadline)
-		VALUES ($1, $2, $3,$4, $5)
+		INSERT INTO todos (user_id, title, status,description,deadline, project_id, position, auto_complete, recurrence,
+		                   priority, completed_at)
+		VALUES ($1, $2, $3,$4, $5, $6, (
+			SELECT COALESCE(MAX(position) + 1, 0)
+			FROM todos
+			WHERE project_id = $6
+		), $7, $8, COALESCE(NULLIF($9, '')::priority, 'P3'), CASE WHEN $3 = 'Completed' THEN NOW() END)
+		RETURNING id
 	`
-	_, err := database.Todo.Exec(query, userId, title, status, description, deadline)
+	var todoID string
+	err := tx.Get(
+		&todoID,
+		query,
+		userId,
+		todo.Title,
+		todo.Status,
+		todo.Description,
+		todo.Deadline,
+		todo.ProjectID,
+		todo.AutoComplete,
+		todo.Recurrence,
+		todo.Priority,
+	)
 	if err != nil {
-		return err
+		return "", err
 	}
-	return nil
+	return todoID, nil
 }
 
-func UpdateTodoData(userID, todoID, Title, Status, Description string, Deadline time.Time) error {
+// UpdateTodoData replaces the data of a todo. The status is left alone, as
+// status changes go through UpdateStatus.
+func UpdateTodoData(tx *sqlx.Tx, userID, todoID string, todo model.Todo) error {
+	if err := SkipPassedReminders(tx, userID, todoID, todo.Deadline); err != nil {
+		return err
+	}
+
+	// a todo moved into another project goes to the end of that project
 	query := `
 		UPDATE todos
-		SET title = $1, status = $2, description = $3, deadline = $4
-		WHERE id = $5 AND user_id = $6
+		SET title = $1, description = $2, deadline = $3,
+		    position = CASE
+		        WHEN project_id IS DISTINCT FROM $6::uuid THEN (
+		            SELECT COALESCE(MAX(position) + 1, 0)
+		            FROM todos
+		            WHERE project_id = $6::uuid
+		        )
+		        ELSE position
+		    END,
+		    project_id = $6::uuid,
+		    auto_complete = $7,
+		    recurrence = $8,
+		    priority = COALESCE(NULLIF($9, '')::priority, priority),
+		    version = version + 1
+		WHERE id = $4 AND user_id = $5
 	`
 
-	_, err := database.Todo.Exec(query, Title, Status, Description, Deadline, todoID, userID)
+	_, err := tx.Exec(
+		query,
+		todo.Title,
+		todo.Description,
+		todo.Deadline,
+		todoID,
+		userID,
+		todo.ProjectID,
+		todo.AutoComplete,
+		todo.Recurrence,
+		todo.Priority,
+	)
 
 	if err != nil {
 		return err
@@ -41,7 +118,7 @@ func GetTodoByID(todoID, userID string) (*model.Todo, error) {
 	var todo model.Todo
 
 	query := `
-		SELECT id, title, status, description, deadline
+		SELECT ` + todoColumns + `
 		FROM todos
 		WHERE id = $1
 		  AND user_id = $2
@@ -59,59 +136,365 @@ func GetTodoByID(todoID, userID string) (*model.Todo, error) {
 	return &todo, nil
 }
 
-func GetDeadline(todoID, userID string) (time.Time, error) {
-	var deadline time.Time
+// GetTodoTx reads a todo inside tx, deleted todos included, so a change can be
+// compared with the state before it.
+func GetTodoTx(tx *sqlx.Tx, todoID, userID string) (*model.Todo, error) {
+	var todo model.Todo
 
-	queryDeadline := `
-		SELECT deadline
+	query := `
+		SELECT ` + todoColumns + `
 		FROM todos
-		WHERE id = $1 AND user_id = $2 AND archived_at IS NULL`
+		WHERE id = $1
+		  AND user_id = $2
+	`
 
-	err := database.Todo.Get(&deadline, queryDeadline, todoID, userID)
+	err := tx.Get(&todo, query, todoID, userID)
 	if err != nil {
-		return time.Time{}, err
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
 	}
-	return deadline, nil
 
+	return &todo, nil
 }
-func UpdateStatus(todoID, userID, status string) error {
+
+// LockTodoVersion locks an active todo until tx ends and returns its version.
+func LockTodoVersion(tx *sqlx.Tx, todoID, userID string) (int, error) {
+	query := `
+		SELECT version
+		FROM todos
+		WHERE id = $1
+		  AND user_id = $2
+		  AND archived_at IS NULL
+		FOR UPDATE
+	`
+
+	var version int
+	err := tx.Get(&version, query, todoID, userID)
+	return version, err
+}
+
+// UpdateStatus sets the status of a todo, recording when it was completed and,
+// with late, that this happened after its deadline.
+func UpdateStatus(tx *sqlx.Tx, todoID, userID, status string, late bool) error {
 	queryUpdate := `
 		UPDATE todos
-		SET status = $1
+		SET status = $1,
+		    status_before_overdue = NULL,
+		    completed_at = CASE WHEN $1 = 'Completed' THEN COALESCE(completed_at, NOW()) END,
+		    completed_late = $4 AND $1 = 'Completed',
+		    version = version + 1
 		WHERE id = $2 AND user_id = $3
 	`
 
-	_, err := database.Todo.Exec(queryUpdate, status, todoID, userID)
+	_, err := tx.Exec(queryUpdate, status, todoID, userID, late)
 	return err
 }
-func DeleteTodo(userID, todoID string) error {
+
+// MarkOverdueTodos moves the open todos whose deadline has passed to the Overdue
+// status, keeping the status they had, and records each change in their audit
+// trail as made by the server.
+func MarkOverdueTodos(tx *sqlx.Tx) (int64, error) {
+	return transitionOverdueTodos(tx, `
+		SELECT id, status
+		FROM todos
+		WHERE archived_at IS NULL
+		  AND status IN ('Pending', 'Not Completed')
+		  AND deadline < NOW()
+		FOR UPDATE
+	`, `status = 'Overdue', status_before_overdue = old.status`)
+}
+
+// ReopenOverdueTodos moves Overdue todos whose deadline was removed or moved into
+// the future back to the status they had before, Pending when it is unknown.
+func ReopenOverdueTodos(tx *sqlx.Tx) (int64, error) {
+	return transitionOverdueTodos(tx, `
+		SELECT id, status
+		FROM todos
+		WHERE archived_at IS NULL
+		  AND status = 'Overdue'
+		  AND (deadline IS NULL OR deadline >= NOW())
+		FOR UPDATE
+	`, `status = COALESCE(t.status_before_overdue, 'Pending'), status_before_overdue = NULL`)
+}
+
+// transitionOverdueTodos applies set, an assignment list that may refer to the
+// current row as old, to the todos selected by query, which returns their id and
+// current status, with one history entry and one outbox event each. The event
+// carries the todo ID and status only.
+func transitionOverdueTodos(tx *sqlx.Tx, query, set string) (int64, error) {
+	queryUpdate := `
+		WITH changed AS (
+			UPDATE todos t
+			SET ` + set + `, version = t.version + 1
+			FROM (` + query + `) old
+			WHERE t.id = old.id
+			RETURNING t.id, t.user_id, t.status::text AS status,
+			          jsonb_build_object('status', jsonb_build_object('old', old.status, 'new', t.status::text)) AS changes
+		), history AS (
+			INSERT INTO todo_history (todo_id, user_id, action, changes)
+			SELECT id, user_id, 'status_changed', changes
+			FROM changed
+		)
+		INSERT INTO outbox_events (user_id, todo_id, event, payload)
+		SELECT user_id, id, 'todo.status_changed',
+		       jsonb_build_object('todo', jsonb_build_object('id', id, 'status', status), 'changes', changes)
+		FROM changed
+	`
+
+	result, err := tx.Exec(queryUpdate)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CreateNextOccurrence copies a recurring todo, with its tags, reminders and a fresh
+// checklist, into a Pending todo of the same series due at deadline. Each occurrence is
+// followed by at most one next occurrence; an empty ID is returned when it exists already.
+func CreateNextOccurrence(tx *sqlx.Tx, userID, todoID string, deadline time.Time) (string, error) {
+	querySeries := `
+		UPDATE todos
+		SET series_id = id
+		WHERE id = $1 AND user_id = $2 AND series_id IS NULL
+	`
+	if _, err := tx.Exec(querySeries, todoID, userID); err != nil {
+		return "", err
+	}
+
+	queryInsert := `
+		INSERT INTO todos (user_id, title, status, description, deadline, project_id, position,
+		                   auto_complete, recurrence, series_id, previous_id, priority)
+		SELECT t.user_id, t.title, 'Pending', t.description, $3, t.project_id, (
+			SELECT COALESCE(MAX(position) + 1, 0)
+			FROM todos
+			WHERE project_id = t.project_id
+		), t.auto_complete, t.recurrence, t.series_id, t.id, t.priority
+		FROM todos t
+		WHERE t.id = $1
+		  AND t.user_id = $2
+		  AND NOT EXISTS (SELECT 1 FROM todos n WHERE n.previous_id = t.id)
+		RETURNING id
+	`
+	var nextID string
+	err := tx.Get(&nextID, queryInsert, todoID, userID, deadline)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	queryTags := `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT $1, tag_id
+		FROM todo_tags
+		WHERE todo_id = $2
+	`
+	if _, err := tx.Exec(queryTags, nextID, todoID); err != nil {
+		return "", err
+	}
+
+	queryItems := `
+		INSERT INTO todo_items (todo_id, title, position)
+		SELECT $1, title, position
+		FROM todo_items
+		WHERE todo_id = $2
+	`
+	if _, err := tx.Exec(queryItems, nextID, todoID); err != nil {
+		return "", err
+	}
+
+	if err := CopyTodoReminders(tx, todoID, nextID); err != nil {
+		return "", err
+	}
+
+	return nextID, nil
+}
+
+// GetTodoSeries returns every occurrence of the series the todo belongs to, oldest first.
+func GetTodoSeries(todoID, userID string) ([]model.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE user_id = $2
+		  AND archived_at IS NULL
+		  AND COALESCE(series_id, id) = (
+			  SELECT COALESCE(series_id, id)
+			  FROM todos
+			  WHERE id = $1 AND user_id = $2
+		  )
+		ORDER BY deadline, created_at
+	`
+
+	todos := []model.Todo{}
+	err := database.Todo.Select(&todos, query, todoID, userID)
+	return todos, err
+}
+
+// DeleteTodo moves a todo to the trash and reports whether it did, which it
+// does not for todos that are missing or already deleted.
+func DeleteTodo(tx *sqlx.Tx, userID, todoID string) (bool, error) {
 	query := `
 		UPDATE todos
-		SET archived_at = NOW()
+		SET archived_at = NOW(), version = version + 1
 		WHERE id = $1
 		  AND user_id = $2
 		  AND archived_at IS NULL
 	`
 
-	_, err := database.Todo.Exec(query, todoID, userID)
+	result, err := tx.Exec(query, todoID, userID)
 	if err != nil {
-		return err
+		return false, err
 	}
-	return nil
+	rows, err := result.RowsAffected()
+	return rows > 0, err
 }
 
-func GetTodos(
-	userID string,
-	status string,
-	selectedDate *time.Time,
-	limit int,
-	offset int,
-) ([]model.Todo, error) {
+// todoSortExpressions whitelists the fields GET /todos can be sorted by.
+var todoSortExpressions = map[string]string{
+	"title":      "LOWER(title)",
+	"status":     "status",
+	"priority":   "priority",
+	"deadline":   "deadline",
+	"created_at": "created_at",
+}
 
-	query := `
-		SELECT id, title, description, status, deadline, created_at
-		FROM todos
-		WHERE user_id = $1
+func IsTodoSortField(field string) bool {
+	_, ok := todoSortExpressions[field]
+	return ok
+}
+
+// todoSortTypes are the SQL types the cursor values of each sort key are cast back to.
+var todoSortTypes = map[string]string{
+	"title":      "text",
+	"status":     "status",
+	"priority":   "priority",
+	"deadline":   "timestamp",
+	"created_at": "timestamptz",
+	"position":   "int",
+	"id":         "uuid",
+}
+
+// todoSortKeys resolves sort into every key todos are ordered by, ending with id
+// so that the order is total. Without sort, todos of a project keep their manual
+// order and others show newest first.
+func todoSortKeys(filter model.TodoFilter, sort []model.SortField) []model.SortField {
+	keys := append([]model.SortField{}, sort...)
+	if len(sort) == 0 {
+		if filter.ProjectID != nil {
+			keys = append(keys, model.SortField{Field: "position"})
+		}
+		keys = append(keys, model.SortField{Field: "created_at", Desc: true})
+	}
+	return append(keys, model.SortField{Field: "id"})
+}
+
+func todoSortExpression(field string) string {
+	if expression, ok := todoSortExpressions[field]; ok {
+		return expression
+	}
+	return field
+}
+
+// todoSortSignature names the sort keys in the form of the ?sort= parameter.
+func todoSortSignature(keys []model.SortField) string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.Field
+		if key.Desc {
+			names[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// IsTodoCursorValid reports whether cursor was made for the same sort of GET /todos.
+func IsTodoCursorValid(filter model.TodoFilter, sort []model.SortField, cursor model.TodoCursor) bool {
+	keys := todoSortKeys(filter, sort)
+	return cursor.Sort == todoSortSignature(keys) && len(cursor.Keys) == len(keys)
+}
+
+// todoOrderBy turns keys into an ORDER BY list with missing values last,
+// or into the exact opposite order when reverse is set.
+func todoOrderBy(keys []model.SortField, reverse bool) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		direction, nulls := "ASC", "NULLS LAST"
+		if key.Desc != reverse {
+			direction = "DESC"
+		}
+		if reverse {
+			nulls = "NULLS FIRST"
+		}
+		parts[i] = todoSortExpression(key.Field) + " " + direction + " " + nulls
+	}
+	return strings.Join(parts, ", ")
+}
+
+// todoSortKeysColumn selects the sort key values of the current row as a JSON
+// array of strings, which cursors are built from.
+func todoSortKeysColumn(keys []model.SortField) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = todoSortExpression(key.Field) + "::text"
+	}
+	return "array_to_json(ARRAY[" + strings.Join(parts, ", ") + "])::text AS sort_keys"
+}
+
+// todoCursorCondition restricts todos to those following the todo of cursor in
+// the order of keys, or preceding it for a Before cursor. Its parameters are
+// numbered from first on.
+func todoCursorCondition(keys []model.SortField, cursor model.TodoCursor, first int) (string, []interface{}) {
+	var args []interface{}
+	var equal, alternatives []string
+
+	for i, key := range keys {
+		expression := todoSortExpression(key.Field)
+		value := cursor.Keys[i]
+
+		var param string
+		if value != nil {
+			args = append(args, *value)
+			param = "$" + strconv.Itoa(first+len(args)-1) + "::" + todoSortTypes[key.Field]
+		}
+
+		// missing values come last in either direction
+		var beyond string
+		switch {
+		case value == nil && cursor.Before:
+			beyond = expression + " IS NOT NULL"
+		case value == nil:
+		case cursor.Before != key.Desc:
+			beyond = expression + " < " + param
+		default:
+			beyond = expression + " > " + param
+		}
+		if beyond != "" && value != nil && !cursor.Before {
+			beyond = "(" + beyond + " OR " + expression + " IS NULL)"
+		}
+		if beyond != "" {
+			alternatives = append(alternatives, strings.Join(append(equal[:len(equal):len(equal)], beyond), " AND "))
+		}
+
+		if value == nil {
+			equal = append(equal, expression+" IS NULL")
+		} else {
+			equal = append(equal, expression+" = "+param)
+		}
+	}
+
+	if len(alternatives) == 0 {
+		return "FALSE", args
+	}
+	return "((" + strings.Join(alternatives, ") OR (") + "))", args
+}
+
+// todoFilterConditions restricts todos to the user's active todos matching the
+// fixed fields of a model.TodoFilter. It uses the parameters $1 to $6 built by
+// todoFilterArgs; todoFilter adds the terms of the filter query.
+const todoFilterConditions = `user_id = $1
 		  AND archived_at IS NULL
 		  AND (
 			  $2 = '' OR status = $2::status
@@ -119,25 +502,270 @@ func GetTodos(
 		  AND (
 			  $3::timestamp IS NULL OR deadline <= $3::timestamp
 		  )
-		ORDER BY created_at DESC
-		LIMIT $4 OFFSET $5
+		  AND (
+			  COALESCE(cardinality($4::text[]), 0) = 0
+			  OR (
+				  $5 = 'all' AND (
+					  SELECT COUNT(DISTINCT LOWER(tg.name))
+					  FROM todo_tags tt
+					  JOIN tags tg ON tg.id = tt.tag_id
+					  WHERE tt.todo_id = todos.id
+					    AND LOWER(tg.name) = ANY($4::text[])
+				  ) = cardinality($4::text[])
+			  )
+			  OR (
+				  $5 <> 'all' AND EXISTS (
+					  SELECT 1
+					  FROM todo_tags tt
+					  JOIN tags tg ON tg.id = tt.tag_id
+					  WHERE tt.todo_id = todos.id
+					    AND LOWER(tg.name) = ANY($4::text[])
+				  )
+			  )
+		  )
+		  AND (
+			  $6::uuid IS NULL OR project_id = $6::uuid
+		  )`
+
+func todoFilterArgs(userID string, filter model.TodoFilter) []interface{} {
+	return []interface{}{
+		userID,
+		filter.Status,
+		filter.Deadline,
+		pq.Array(lowerAll(filter.Tags)),
+		filter.TagMode,
+		filter.ProjectID,
+	}
+}
+
+// todoPageRow is a todo read together with its sort key values.
+type todoPageRow struct {
+	model.Todo
+	SortKeys string `db:"sort_keys"`
+}
+
+// GetTodos returns a page of at most limit todos matching filter in the order of sort.
+// With a cursor the page follows or precedes its todo, otherwise the first offset
+// todos are skipped. The page carries cursors to the pages around it.
+func GetTodos(
+	userID string,
+	filter model.TodoFilter,
+	sort []model.SortField,
+	cursor *model.TodoCursor,
+	limit int,
+	offset int,
+) (model.TodoPage, error) {
+	keys := todoSortKeys(filter, sort)
+	before := cursor != nil && cursor.Before
+
+	conditions, args := todoFilter(userID, filter)
+	if cursor != nil {
+		condition, cursorArgs := todoCursorCondition(keys, *cursor, len(args)+1)
+		conditions += "\n\t\t  AND " + condition
+		args = append(args, cursorArgs...)
+		offset = 0
+	}
+
+	// one extra row tells whether there is a further page
+	args = append(args, limit+1, offset)
+	query := `
+		SELECT ` + todoColumns + `, ` + todoSortKeysColumn(keys) + `
+		FROM todos
+		WHERE ` + conditions + `
+		ORDER BY ` + todoOrderBy(keys, before) + `
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args)) + `
 	`
 
-	todos := []model.Todo{}
+	rows := []todoPageRow{}
+	if err := database.Todo.Select(&rows, query, args...); err != nil {
+		return model.TodoPage{}, err
+	}
+
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	if before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	page := model.TodoPage{Todos: make([]model.Todo, len(rows))}
+	for i, row := range rows {
+		page.Todos[i] = row.Todo
+	}
+	if len(rows) == 0 {
+		return page, nil
+	}
+
+	hasNext, hasPrev := more, cursor != nil || offset > 0
+	if before {
+		hasNext, hasPrev = true, more
+	}
+
+	signature := todoSortSignature(keys)
+	if hasNext {
+		page.Next = &model.TodoCursor{Sort: signature}
+		if err := json.Unmarshal([]byte(rows[len(rows)-1].SortKeys), &page.Next.Keys); err != nil {
+			return model.TodoPage{}, err
+		}
+	}
+	if hasPrev {
+		page.Prev = &model.TodoCursor{Sort: signature, Before: true}
+		if err := json.Unmarshal([]byte(rows[0].SortKeys), &page.Prev.Keys); err != nil {
+			return model.TodoPage{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// CountTodos returns how many todos match filter.
+func CountTodos(userID string, filter model.TodoFilter) (int, error) {
+	conditions, args := todoFilter(userID, filter)
+	query := `
+		SELECT COUNT(*)
+		FROM todos
+		WHERE ` + conditions + `
+	`
+
+	var total int
+	err := database.Todo.Get(&total, query, args...)
+	return total, err
+}
+
+// GetTodoIDs returns the IDs of the first limit todos matching filter.
+func GetTodoIDs(tx *sqlx.Tx, userID string, filter model.TodoFilter, limit int) ([]string, error) {
+	conditions, args := todoFilter(userID, filter)
+	args = append(args, limit)
+	query := `
+		SELECT id
+		FROM todos
+		WHERE ` + conditions + `
+		ORDER BY created_at
+		LIMIT $` + strconv.Itoa(len(args)) + `
+	`
+
+	todoIDs := []string{}
+	err := tx.Select(&todoIDs, query, args...)
+	return todoIDs, err
+}
+
+// SearchTodos ranks the todos matching filter against a web-search style query
+// such as `deploy -staging "release notes"`, highlighting the matches with <mark>.
+func SearchTodos(
+	userID string,
+	q string,
+	filter model.TodoFilter,
+	limit int,
+	offset int,
+) ([]model.TodoSearchResult, error) {
+
+	conditions, args := todoFilter(userID, filter)
+	args = append(args, q, limit, offset)
+	tsquery := "websearch_to_tsquery('english', $" + strconv.Itoa(len(args)-2) + ")"
+
+	query := `
+		SELECT ` + todoColumns + `,
+		       ts_rank(search_vector, ` + tsquery + `) AS rank,
+		       ts_headline('english', title, ` + tsquery + `,
+		                   'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS title_snippet,
+		       ts_headline('english', COALESCE(description, ''), ` + tsquery + `,
+		                   'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5') AS description_snippet
+		FROM todos
+		WHERE ` + conditions + `
+		  AND search_vector @@ ` + tsquery + `
+		ORDER BY rank DESC, created_at DESC
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args)) + `
+	`
+
+	results := []model.TodoSearchResult{}
 
 	err := database.Todo.Select(
-		&todos,
+		&results,
 		query,
-		userID,
-		status,
-		selectedDate,
-		limit,
-		offset,
+		args...,
 	)
 
+	return results, err
+}
+
+// GetArchivedTodos lists the user's deleted todos, most recently deleted first.
+func GetArchivedTodos(userID string, limit, offset int) ([]model.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE user_id = $1
+		  AND archived_at IS NOT NULL
+		ORDER BY archived_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	todos := []model.Todo{}
+	err := database.Todo.Select(&todos, query, userID, limit, offset)
 	return todos, err
 }
 
+// RestoreTodo brings a deleted todo back, taking it out of its project when
+// that project has been archived meanwhile. It reports whether a todo was restored.
+func RestoreTodo(tx *sqlx.Tx, userID, todoID string) (bool, error) {
+	query := `
+		UPDATE todos t
+		SET archived_at = NULL,
+		    version = t.version + 1,
+		    project_id = (
+		        SELECT p.id
+		        FROM projects p
+		        WHERE p.id = t.project_id
+		          AND p.archived_at IS NULL
+		    )
+		WHERE t.id = $1
+		  AND t.user_id = $2
+		  AND t.archived_at IS NOT NULL
+	`
+
+	result, err := tx.Exec(query, todoID, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// PurgeArchivedTodos hard-deletes every todo archived before cutoff and returns
+// how many were removed. The todos of deleted users are kept, as reactivating
+// the account brings them back; they go when the user is purged.
+func PurgeArchivedTodos(cutoff time.Time) (int64, error) {
+	query := `
+		DELETE FROM todos t
+		USING users u
+		WHERE u.id = t.user_id
+		  AND u.archived_at IS NULL
+		  AND t.archived_at < $1
+	`
+
+	result, err := database.Todo.Exec(query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteProjectTodos archives the todos of a project and returns their IDs.
+func DeleteProjectTodos(tx *sqlx.Tx, userID, projectID string) ([]string, error) {
+	query := `UPDATE todos
+            SET archived_at = NOW(), version = version + 1
+            WHERE user_id = $1
+            AND project_id = $2
+            AND archived_at IS NULL
+            RETURNING id`
+
+	todoIDs := []string{}
+	err := tx.Select(&todoIDs, query, userID, projectID)
+	return todoIDs, err
+}
+
 func DeleteAllTodos(tx *sqlx.Tx, userID string) error {
 	query := `UPDATE todos 
             SET archived_at = NOW()
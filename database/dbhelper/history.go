@@ -0,0 +1,61 @@
+package dbhelper
+
+import (
+	"encoding/json"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func CreateTodoHistory(tx *sqlx.Tx, todoID, userID string, sessionID *int64, action string, changes map[string]model.FieldChange) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO todo_history (todo_id, user_id, session_id, action, changes)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = tx.Exec(query, todoID, userID, sessionID, action, data)
+	return err
+}
+
+// CreateTodoHistories records the same action for many todos of a user at once,
+// with changes[i] made to todoIDs[i].
+func CreateTodoHistories(tx *sqlx.Tx, userID string, sessionID *int64, action string, todoIDs []string, changes []map[string]model.FieldChange) error {
+	data := make([]string, len(changes))
+	for i := range changes {
+		encoded, err := json.Marshal(changes[i])
+		if err != nil {
+			return err
+		}
+		data[i] = string(encoded)
+	}
+
+	query := `
+		INSERT INTO todo_history (todo_id, user_id, session_id, action, changes)
+		SELECT h.todo_id, $1, $2, $3, h.changes::jsonb
+		FROM UNNEST($4::uuid[], $5::text[]) WITH ORDINALITY AS h(todo_id, changes, ord)
+		ORDER BY h.ord
+	`
+	_, err := tx.Exec(query, userID, sessionID, action, pq.Array(todoIDs), pq.Array(data))
+	return err
+}
+
+// GetTodoHistory returns the audit trail of a todo, deleted todos included, oldest first.
+func GetTodoHistory(todoID, userID string) ([]model.TodoHistory, error) {
+	query := `
+		SELECT id, todo_id, session_id, action, changes, created_at
+		FROM todo_history
+		WHERE todo_id = $1
+		  AND user_id = $2
+		ORDER BY created_at, id
+	`
+
+	history := []model.TodoHistory{}
+	err := database.Todo.Select(&history, query, todoID, userID)
+	return history, err
+}
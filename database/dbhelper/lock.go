@@ -0,0 +1,11 @@
+package dbhelper
+
+import "github.com/jmoiron/sqlx"
+
+// TryAdvisoryLock takes the transaction-level Postgres advisory lock key without
+// waiting, reporting whether it got it. The lock is released with the transaction.
+func TryAdvisoryLock(tx *sqlx.Tx, key int64) (bool, error) {
+	var locked bool
+	err := tx.Get(&locked, `SELECT pg_try_advisory_xact_lock($1)`, key)
+	return locked, err
+}
@@ -0,0 +1,174 @@
+package dbhelper
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func CreateProject(userID, name, description string) (string, error) {
+	query := `
+		INSERT INTO projects (user_id, name, description)
+		VALUES ($1, TRIM($2), $3)
+		RETURNING id
+	`
+
+	var projectID string
+	err := database.Todo.Get(&projectID, query, userID, name, description)
+	if err != nil {
+		return "", err
+	}
+	return projectID, nil
+}
+
+func GetProjects(userID string) ([]model.Project, error) {
+	query := `
+		SELECT p.id, p.name, p.description, p.created_at, p.archived_at, COUNT(t.id) AS todo_count
+		FROM projects p
+		LEFT JOIN todos t ON t.project_id = p.id AND t.archived_at IS NULL
+		WHERE p.user_id = $1
+		  AND p.archived_at IS NULL
+		GROUP BY p.id
+		ORDER BY p.created_at
+	`
+
+	projects := []model.Project{}
+	err := database.Todo.Select(&projects, query, userID)
+	return projects, err
+}
+
+func GetProjectByID(userID, projectID string) (*model.Project, error) {
+	var project model.Project
+
+	query := `
+		SELECT p.id, p.name, p.description, p.created_at, p.archived_at, COUNT(t.id) AS todo_count
+		FROM projects p
+		LEFT JOIN todos t ON t.project_id = p.id AND t.archived_at IS NULL
+		WHERE p.id = $1
+		  AND p.user_id = $2
+		  AND p.archived_at IS NULL
+		GROUP BY p.id
+	`
+
+	err := database.Todo.Get(&project, query, projectID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func UpdateProject(userID, projectID, name, description string) error {
+	query := `
+		UPDATE projects
+		SET name = TRIM($1), description = $2
+		WHERE id = $3
+		  AND user_id = $4
+		  AND archived_at IS NULL
+	`
+	_, err := database.Todo.Exec(query, name, description, projectID, userID)
+	return err
+}
+
+func ArchiveProject(tx *sqlx.Tx, userID, projectID string) error {
+	query := `
+		UPDATE projects
+		SET archived_at = NOW()
+		WHERE id = $1
+		  AND user_id = $2
+		  AND archived_at IS NULL
+	`
+	_, err := tx.Exec(query, projectID, userID)
+	return err
+}
+
+// ReorderProjectTodos sets the position of each todo to its index in todoIDs
+// and returns the IDs of the todos that moved. Todos of the project that are
+// not listed keep their current position.
+func ReorderProjectTodos(tx *sqlx.Tx, userID, projectID string, todoIDs []string) ([]string, error) {
+	query := `
+		UPDATE todos t
+		SET position = o.ord, version = t.version + 1
+		FROM UNNEST($1::uuid[]) WITH ORDINALITY AS o(id, ord)
+		WHERE t.id = o.id
+		  AND t.project_id = $2
+		  AND t.user_id = $3
+		  AND t.archived_at IS NULL
+		  AND t.position IS DISTINCT FROM o.ord
+		RETURNING t.id
+	`
+
+	moved := []string{}
+	err := tx.Select(&moved, query, pq.Array(todoIDs), projectID, userID)
+	return moved, err
+}
+
+// GetProjectIDsByName returns the IDs of the active projects of the user with
+// the given names, keyed by lower-cased name.
+func GetProjectIDsByName(userID string, names []string) (map[string]string, error) {
+	return projectIDsByName(database.Todo, userID, names)
+}
+
+// CreateProjects returns the IDs of the active projects of the user with the
+// given names, keyed by lower-cased name, creating the missing ones, whose
+// names it returns too.
+func CreateProjects(tx *sqlx.Tx, userID string, names []string) (map[string]string, []string, error) {
+	projectIDs, err := projectIDsByName(tx, userID, names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		INSERT INTO projects (user_id, name)
+		VALUES ($1, TRIM($2))
+		RETURNING id
+	`
+
+	var created []string
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := projectIDs[key]; ok {
+			continue
+		}
+
+		var projectID string
+		if err := tx.Get(&projectID, query, userID, name); err != nil {
+			return nil, nil, err
+		}
+		projectIDs[key] = projectID
+		created = append(created, name)
+	}
+	return projectIDs, created, nil
+}
+
+func projectIDsByName(q sqlx.Queryer, userID string, names []string) (map[string]string, error) {
+	query := `
+		SELECT DISTINCT ON (LOWER(name)) LOWER(name) AS name, id
+		FROM projects
+		WHERE user_id = $1
+		  AND archived_at IS NULL
+		  AND LOWER(name) = ANY($2::text[])
+		ORDER BY LOWER(name), created_at
+	`
+
+	var rows []struct {
+		Name string `db:"name"`
+		ID   string `db:"id"`
+	}
+	if err := sqlx.Select(q, &rows, query, userID, pq.Array(lowerAll(names))); err != nil {
+		return nil, err
+	}
+
+	projectIDs := make(map[string]string, len(rows))
+	for _, row := range rows {
+		projectIDs[row.Name] = row.ID
+	}
+	return projectIDs, nil
+}
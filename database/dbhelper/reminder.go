@@ -0,0 +1,220 @@
+package dbhelper
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// reminderPassed tells whether the time of reminder r has passed for the
+// deadline of todo t. Such reminders are created already done for that deadline
+// rather than firing at once about a deadline that is no longer ahead by their offset.
+const reminderPassed = `t.deadline - r.offset_minutes * INTERVAL '1 minute' <= NOW()`
+
+// SetTodoReminders replaces the reminder offsets of a todo. Reminders whose
+// offset is kept keep their delivery state, and new ones whose time has already
+// passed are skipped for the current deadline.
+func SetTodoReminders(tx *sqlx.Tx, todoID string, offsets []int) error {
+	queryDelete := `
+		DELETE FROM todo_reminders
+		WHERE todo_id = $1
+		  AND NOT offset_minutes = ANY($2::int[])
+	`
+	if _, err := tx.Exec(queryDelete, todoID, pq.Array(offsets)); err != nil {
+		return err
+	}
+
+	queryInsert := `
+		INSERT INTO todo_reminders (todo_id, offset_minutes, done_for, done_at)
+		SELECT t.id, r.offset_minutes,
+		       CASE WHEN ` + reminderPassed + ` THEN t.deadline END,
+		       CASE WHEN ` + reminderPassed + ` THEN NOW() END
+		FROM todos t
+		CROSS JOIN UNNEST($2::int[]) AS r(offset_minutes)
+		WHERE t.id = $1
+		ON CONFLICT (todo_id, offset_minutes) DO NOTHING
+	`
+	_, err := tx.Exec(queryInsert, todoID, pq.Array(offsets))
+	return err
+}
+
+// CopyTodoReminders gives the todo toID the reminder offsets of fromID,
+// skipping those whose time has already passed for the deadline of toID.
+func CopyTodoReminders(tx *sqlx.Tx, fromID, toID string) error {
+	query := `
+		INSERT INTO todo_reminders (todo_id, offset_minutes, done_for, done_at)
+		SELECT t.id, r.offset_minutes,
+		       CASE WHEN ` + reminderPassed + ` THEN t.deadline END,
+		       CASE WHEN ` + reminderPassed + ` THEN NOW() END
+		FROM todo_reminders r
+		JOIN todos t ON t.id = $2
+		WHERE r.todo_id = $1
+		ON CONFLICT (todo_id, offset_minutes) DO NOTHING
+	`
+	_, err := tx.Exec(query, fromID, toID)
+	return err
+}
+
+// SkipPassedReminders marks the reminders of a todo whose time has already
+// passed for deadline as done for it. It is called before the deadline of the
+// todo changes, so moving a deadline closer does not fire those reminders at once.
+func SkipPassedReminders(tx *sqlx.Tx, userID, todoID string, deadline *time.Time) error {
+	query := `
+		UPDATE todo_reminders r
+		SET done_for = $3::timestamp, done_at = NOW()
+		FROM todos t
+		WHERE t.id = r.todo_id
+		  AND t.id = $1
+		  AND t.user_id = $2
+		  AND t.deadline IS DISTINCT FROM $3::timestamp
+		  AND $3::timestamp - r.offset_minutes * INTERVAL '1 minute' <= NOW()
+	`
+	_, err := tx.Exec(query, todoID, userID, deadline)
+	return err
+}
+
+func GetTodoReminders(todoID, userID string) ([]model.Reminder, error) {
+	query := `
+		SELECT r.id, r.offset_minutes,
+		       t.deadline - r.offset_minutes * INTERVAL '1 minute' AS remind_at,
+		       COALESCE(r.done_for = t.deadline, FALSE) AS done,
+		       r.done_at, r.created_at
+		FROM todo_reminders r
+		JOIN todos t ON t.id = r.todo_id
+		WHERE r.todo_id = $1
+		  AND t.user_id = $2
+		  AND t.archived_at IS NULL
+		ORDER BY r.offset_minutes DESC
+	`
+
+	reminders := []model.Reminder{}
+	err := database.Todo.Select(&reminders, query, todoID, userID)
+	return reminders, err
+}
+
+// GetReminderDeliveries returns the delivery attempts of the reminders of a todo, newest first.
+func GetReminderDeliveries(todoID, userID string, limit, offset int) ([]model.ReminderDelivery, error) {
+	query := `
+		SELECT d.id, d.reminder_id, r.offset_minutes, d.deadline, d.notifier, d.success, d.error, d.attempted_at
+		FROM reminder_deliveries d
+		JOIN todo_reminders r ON r.id = d.reminder_id
+		JOIN todos t ON t.id = r.todo_id
+		WHERE r.todo_id = $1
+		  AND t.user_id = $2
+		ORDER BY d.attempted_at DESC, d.id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	deliveries := []model.ReminderDelivery{}
+	err := database.Todo.Select(&deliveries, query, todoID, userID, limit, offset)
+	return deliveries, err
+}
+
+// ClaimDueReminder leases a reminder of an open todo that is due and has not
+// been handled for the todo's current deadline, skipping reminders leased by
+// other replicas and those already attempted since attemptedBefore. The
+// reminder can then be delivered outside of any transaction; should it never be
+// released, it is taken again once the lease is over.
+func ClaimDueReminder(attemptedBefore time.Time, lease time.Duration) (*model.DueReminder, error) {
+	var reminder model.DueReminder
+
+	query := `
+		WITH due AS (
+			SELECT r.id
+			FROM todo_reminders r
+			JOIN todos t ON t.id = r.todo_id
+			JOIN users u ON u.id = t.user_id
+			WHERE t.archived_at IS NULL
+			  AND u.archived_at IS NULL
+			  AND t.status <> 'Completed'
+			  AND t.deadline IS NOT NULL
+			  AND t.deadline - r.offset_minutes * INTERVAL '1 minute' <= NOW()
+			  AND r.done_for IS DISTINCT FROM t.deadline
+			  AND (r.leased_until IS NULL OR r.leased_until < NOW())
+			  AND NOT EXISTS (
+				  SELECT 1
+				  FROM reminder_deliveries d
+				  WHERE d.reminder_id = r.id
+				    AND d.attempted_at >= $1
+			  )
+			ORDER BY t.deadline - r.offset_minutes * INTERVAL '1 minute'
+			LIMIT 1
+			FOR UPDATE OF r SKIP LOCKED
+		), claimed AS (
+			UPDATE todo_reminders r
+			SET leased_until = NOW() + make_interval(secs => $2)
+			FROM due
+			WHERE r.id = due.id
+			RETURNING r.id, r.todo_id, r.offset_minutes
+		)
+		SELECT c.id AS reminder_id, t.id AS todo_id, t.title, t.deadline, c.offset_minutes,
+		       u.id AS user_id, u.name, u.email
+		FROM claimed c
+		JOIN todos t ON t.id = c.todo_id
+		JOIN users u ON u.id = t.user_id
+	`
+
+	err := database.Todo.Get(&reminder, query, attemptedBefore, lease.Seconds())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &reminder, nil
+}
+
+// GetReminderAttempts sums up the deliveries of a reminder for deadline by notifier.
+func GetReminderAttempts(reminderID string, deadline time.Time) ([]model.ReminderAttempts, error) {
+	query := `
+		SELECT notifier,
+		       BOOL_OR(success) AS succeeded,
+		       COUNT(*) FILTER (WHERE NOT success) AS failures
+		FROM reminder_deliveries
+		WHERE reminder_id = $1
+		  AND deadline = $2
+		GROUP BY notifier
+	`
+
+	attempts := []model.ReminderAttempts{}
+	err := database.Todo.Select(&attempts, query, reminderID, deadline)
+	return attempts, err
+}
+
+// CreateReminderDelivery records one delivery attempt, failed when errMsg is set.
+func CreateReminderDelivery(tx *sqlx.Tx, reminderID string, deadline time.Time, notifier string, errMsg *string) error {
+	query := `
+		INSERT INTO reminder_deliveries (reminder_id, deadline, notifier, success, error)
+		VALUES ($1, $2, $3, $4 IS NULL, $4)
+	`
+	_, err := tx.Exec(query, reminderID, deadline, notifier, errMsg)
+	return err
+}
+
+// MarkReminderDone marks a reminder as handled for deadline, so that it comes
+// due again only once the deadline changes.
+func MarkReminderDone(tx *sqlx.Tx, reminderID string, deadline time.Time) error {
+	query := `
+		UPDATE todo_reminders
+		SET done_for = $2, done_at = NOW()
+		WHERE id = $1
+	`
+	_, err := tx.Exec(query, reminderID, deadline)
+	return err
+}
+
+// ReleaseReminder ends the lease taken on a reminder by ClaimDueReminder.
+func ReleaseReminder(tx *sqlx.Tx, reminderID string) error {
+	query := `
+		UPDATE todo_reminders
+		SET leased_until = NULL
+		WHERE id = $1
+	`
+	_, err := tx.Exec(query, reminderID)
+	return err
+}
@@ -0,0 +1,94 @@
+package dbhelper
+
+import (
+	"database/sql"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+func CreateSavedFilter(userID string, filter model.SavedFilterRequest) (string, error) {
+	query := `
+		INSERT INTO saved_filters (user_id, name, filter, sort, page_size)
+		VALUES ($1, TRIM($2), $3, $4, $5)
+		RETURNING id
+	`
+
+	var filterID string
+	err := database.Todo.Get(&filterID, query, userID, filter.Name, filter.Filter, filter.Sort, filter.PageSize)
+	if err != nil {
+		return "", err
+	}
+	return filterID, nil
+}
+
+func GetSavedFilters(userID string) ([]model.SavedFilter, error) {
+	query := `
+		SELECT id, name, filter, sort, page_size, created_at
+		FROM saved_filters
+		WHERE user_id = $1
+		ORDER BY LOWER(name)
+	`
+
+	filters := []model.SavedFilter{}
+	err := database.Todo.Select(&filters, query, userID)
+	return filters, err
+}
+
+func GetSavedFilterByID(userID, filterID string) (*model.SavedFilter, error) {
+	var filter model.SavedFilter
+
+	query := `
+		SELECT id, name, filter, sort, page_size, created_at
+		FROM saved_filters
+		WHERE id = $1
+		  AND user_id = $2
+	`
+
+	err := database.Todo.Get(&filter, query, filterID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &filter, nil
+}
+
+// IsSavedFilterNameTaken reports whether a saved filter of the user other than
+// filterID, which is empty for a new one, already has the name in any case.
+func IsSavedFilterNameTaken(userID, filterID, name string) (bool, error) {
+	query := `
+		SELECT COUNT(*) > 0
+		FROM saved_filters
+		WHERE user_id = $1
+		  AND id::text <> $2
+		  AND LOWER(name) = LOWER(TRIM($3))
+	`
+
+	var taken bool
+	err := database.Todo.Get(&taken, query, userID, filterID, name)
+	return taken, err
+}
+
+func UpdateSavedFilter(userID, filterID string, filter model.SavedFilterRequest) error {
+	query := `
+		UPDATE saved_filters
+		SET name = TRIM($1), filter = $2, sort = $3, page_size = $4
+		WHERE id = $5
+		  AND user_id = $6
+	`
+	_, err := database.Todo.Exec(query, filter.Name, filter.Filter, filter.Sort, filter.PageSize, filterID, userID)
+	return err
+}
+
+func DeleteSavedFilter(userID, filterID string) error {
+	query := `
+		DELETE FROM saved_filters
+		WHERE id = $1
+		  AND user_id = $2
+	`
+	_, err := database.Todo.Exec(query, filterID, userID)
+	return err
+}
@@ -0,0 +1,45 @@
+package dbhelper
+
+import (
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+)
+
+func GetUserSettings(userID string) (model.UserSettings, error) {
+	query := `
+		SELECT late_completion
+		FROM users
+		WHERE id = $1
+		  AND archived_at IS NULL
+	`
+
+	var settings model.UserSettings
+	err := database.Todo.Get(&settings, query, userID)
+	return settings, err
+}
+
+// GetLateCompletion returns the late completion policy of the user.
+func GetLateCompletion(tx *sqlx.Tx, userID string) (string, error) {
+	query := `
+		SELECT late_completion
+		FROM users
+		WHERE id = $1
+	`
+
+	var policy string
+	err := tx.Get(&policy, query, userID)
+	return policy, err
+}
+
+// UpdateUserSettings changes the settings of the user, keeping the nil ones.
+func UpdateUserSettings(userID string, settings model.UserSettingsPatch) error {
+	query := `
+		UPDATE users
+		SET late_completion = COALESCE($1::late_completion, late_completion)
+		WHERE id = $2
+		  AND archived_at IS NULL
+	`
+	_, err := database.Todo.Exec(query, settings.LateCompletion, userID)
+	return err
+}
@@ -0,0 +1,165 @@
+package dbhelper
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// SetTodoTags replaces the tags of a todo, creating any tag the user does not have yet.
+// Like every tag change it bumps the version of the todo.
+func SetTodoTags(tx *sqlx.Tx, userID, todoID string, tags []string) error {
+	queryCreate := `
+		INSERT INTO tags (user_id, name)
+		SELECT $1, name
+		FROM UNNEST($2::text[]) AS name
+		ON CONFLICT (user_id, LOWER(name)) DO NOTHING
+	`
+	if _, err := tx.Exec(queryCreate, userID, pq.Array(tags)); err != nil {
+		return err
+	}
+
+	queryClear := `
+		WITH touched AS (
+			UPDATE todos SET version = version + 1 WHERE id = $1 AND user_id = $2
+		)
+		DELETE FROM todo_tags
+		WHERE todo_id = $1
+		  AND todo_id IN (SELECT id FROM todos WHERE user_id = $2)
+	`
+	if _, err := tx.Exec(queryClear, todoID, userID); err != nil {
+		return err
+	}
+
+	queryAttach := `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT t.id, tg.id
+		FROM todos t
+		JOIN tags tg ON tg.user_id = t.user_id
+		WHERE t.id = $1
+		  AND t.user_id = $2
+		  AND LOWER(tg.name) = ANY($3::text[])
+	`
+	_, err := tx.Exec(queryAttach, todoID, userID, pq.Array(lowerAll(tags)))
+	return err
+}
+
+func GetTags(userID string) ([]model.Tag, error) {
+	query := `
+		SELECT tg.id, tg.name, tg.created_at, COUNT(t.id) AS todo_count
+		FROM tags tg
+		LEFT JOIN todo_tags tt ON tt.tag_id = tg.id
+		LEFT JOIN todos t ON t.id = tt.todo_id AND t.archived_at IS NULL
+		WHERE tg.user_id = $1
+		GROUP BY tg.id
+		ORDER BY LOWER(tg.name)
+	`
+
+	tags := []model.Tag{}
+	err := database.Todo.Select(&tags, query, userID)
+	return tags, err
+}
+
+func GetTagByID(userID, tagID string) (*model.Tag, error) {
+	var tag model.Tag
+
+	query := `
+		SELECT id, name, created_at
+		FROM tags
+		WHERE id = $1
+		  AND user_id = $2
+	`
+
+	err := database.Todo.Get(&tag, query, tagID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// IsTagNameTaken reports whether another tag of the user already uses name.
+func IsTagNameTaken(userID, tagID, name string) (bool, error) {
+	query := `
+		SELECT COUNT(*) > 0
+		FROM tags
+		WHERE user_id = $1
+		  AND id <> $2
+		  AND LOWER(name) = LOWER(TRIM($3))
+	`
+
+	var taken bool
+	err := database.Todo.Get(&taken, query, userID, tagID, name)
+	return taken, err
+}
+
+func RenameTag(userID, tagID, name string) error {
+	query := `
+		WITH touched AS (
+			UPDATE todos SET version = version + 1
+			WHERE id IN (SELECT todo_id FROM todo_tags WHERE tag_id = $2)
+		)
+		UPDATE tags
+		SET name = TRIM($1)
+		WHERE id = $2 AND user_id = $3
+	`
+	_, err := database.Todo.Exec(query, name, tagID, userID)
+	return err
+}
+
+// MergeTags moves every todo of the source tag onto the target tag and removes the source tag.
+func MergeTags(tx *sqlx.Tx, userID, sourceID, targetID string) error {
+	queryMove := `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT tt.todo_id, target.id
+		FROM todo_tags tt
+		JOIN tags source ON source.id = tt.tag_id
+		JOIN tags target ON target.user_id = source.user_id
+		WHERE source.id = $1
+		  AND target.id = $2
+		  AND source.user_id = $3
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.Exec(queryMove, sourceID, targetID, userID); err != nil {
+		return err
+	}
+
+	queryTouch := `
+		UPDATE todos SET version = version + 1
+		WHERE id IN (SELECT todo_id FROM todo_tags WHERE tag_id = $1)
+	`
+	if _, err := tx.Exec(queryTouch, sourceID); err != nil {
+		return err
+	}
+
+	queryDelete := `DELETE FROM tags WHERE id = $1 AND user_id = $2`
+	_, err := tx.Exec(queryDelete, sourceID, userID)
+	return err
+}
+
+func DeleteTag(userID, tagID string) error {
+	query := `
+		WITH touched AS (
+			UPDATE todos SET version = version + 1
+			WHERE id IN (SELECT todo_id FROM todo_tags WHERE tag_id = $1)
+		)
+		DELETE FROM tags WHERE id = $1 AND user_id = $2
+	`
+	_, err := database.Todo.Exec(query, tagID, userID)
+	return err
+}
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, 0, len(values))
+	for _, v := range values {
+		lowered = append(lowered, strings.ToLower(v))
+	}
+	return lowered
+}
@@ -0,0 +1,160 @@
+package dbhelper
+
+import (
+	"database/sql"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func GetTodoItems(todoID, userID string) ([]model.TodoItem, error) {
+	query := `
+		SELECT i.id, i.title, i.done, i.position, i.created_at
+		FROM todo_items i
+		JOIN todos t ON t.id = i.todo_id
+		WHERE i.todo_id = $1
+		  AND t.user_id = $2
+		  AND t.archived_at IS NULL
+		ORDER BY i.position, i.created_at
+	`
+
+	items := []model.TodoItem{}
+	err := database.Todo.Select(&items, query, todoID, userID)
+	return items, err
+}
+
+// GetTodoItemsTx reads the checklist of a todo inside tx, in order.
+func GetTodoItemsTx(tx *sqlx.Tx, todoID string) ([]model.TodoItem, error) {
+	query := `
+		SELECT id, title, done, position, created_at
+		FROM todo_items
+		WHERE todo_id = $1
+		ORDER BY position, created_at
+	`
+
+	items := []model.TodoItem{}
+	err := tx.Select(&items, query, todoID)
+	return items, err
+}
+
+func GetTodoItem(todoID, itemID, userID string) (*model.TodoItem, error) {
+	var item model.TodoItem
+
+	query := `
+		SELECT i.id, i.title, i.done, i.position, i.created_at
+		FROM todo_items i
+		JOIN todos t ON t.id = i.todo_id
+		WHERE i.id = $1
+		  AND i.todo_id = $2
+		  AND t.user_id = $3
+		  AND t.archived_at IS NULL
+	`
+
+	err := database.Todo.Get(&item, query, itemID, todoID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// CreateTodoItem appends an item to the checklist of a todo. Like every checklist
+// change it bumps the version of the todo, whose ETag covers the checklist.
+func CreateTodoItem(tx *sqlx.Tx, todoID, title string) (string, error) {
+	query := `
+		WITH touched AS (
+			UPDATE todos SET version = version + 1 WHERE id = $1
+		)
+		INSERT INTO todo_items (todo_id, title, position)
+		VALUES ($1, TRIM($2), (
+			SELECT COALESCE(MAX(position) + 1, 0)
+			FROM todo_items
+			WHERE todo_id = $1
+		))
+		RETURNING id
+	`
+
+	var itemID string
+	err := tx.Get(&itemID, query, todoID, title)
+	if err != nil {
+		return "", err
+	}
+	return itemID, nil
+}
+
+// UpdateTodoItem changes the title and done flag of a checklist item, keeping the nil ones.
+func UpdateTodoItem(tx *sqlx.Tx, todoID, itemID string, title *string, done *bool) error {
+	query := `
+		WITH touched AS (
+			UPDATE todos SET version = version + 1 WHERE id = $4
+		)
+		UPDATE todo_items
+		SET title = COALESCE(TRIM($1), title),
+		    done = COALESCE($2, done)
+		WHERE id = $3 AND todo_id = $4
+	`
+	_, err := tx.Exec(query, title, done, itemID, todoID)
+	return err
+}
+
+func DeleteTodoItem(tx *sqlx.Tx, todoID, itemID string) error {
+	query := `
+		WITH touched AS (
+			UPDATE todos SET version = version + 1 WHERE id = $2
+		)
+		DELETE FROM todo_items WHERE id = $1 AND todo_id = $2
+	`
+	_, err := tx.Exec(query, itemID, todoID)
+	return err
+}
+
+// ReorderTodoItems sets the position of each checklist item to its index in itemIDs.
+func ReorderTodoItems(tx *sqlx.Tx, todoID string, itemIDs []string) error {
+	query := `
+		WITH touched AS (
+			UPDATE todos SET version = version + 1 WHERE id = $2
+		)
+		UPDATE todo_items i
+		SET position = o.ord
+		FROM UNNEST($1::uuid[]) WITH ORDINALITY AS o(id, ord)
+		WHERE i.id = o.id
+		  AND i.todo_id = $2
+	`
+	_, err := tx.Exec(query, pq.Array(itemIDs), todoID)
+	return err
+}
+
+// AreTodoItemsDone reports whether the todo has a checklist and every item of it is done.
+func AreTodoItemsDone(tx *sqlx.Tx, todoID string) (bool, error) {
+	query := `
+		SELECT COUNT(*) > 0 AND COALESCE(BOOL_AND(done), FALSE)
+		FROM todo_items
+		WHERE todo_id = $1
+	`
+
+	var done bool
+	err := tx.Get(&done, query, todoID)
+	return done, err
+}
+
+// CreateTodoItems appends items to the checklists of todos that are being
+// created, the i-th item to the todo todoIDs[i], keeping their order.
+func CreateTodoItems(tx *sqlx.Tx, todoIDs, titles []string, done []bool) error {
+	query := `
+		INSERT INTO todo_items (todo_id, title, done, position)
+		SELECT i.todo_id, TRIM(i.title), i.done,
+		       COALESCE((
+			       SELECT MAX(position) + 1
+			       FROM todo_items
+			       WHERE todo_id = i.todo_id
+		       ), 0) + ROW_NUMBER() OVER (PARTITION BY i.todo_id ORDER BY i.ord) - 1
+		FROM UNNEST($1::uuid[], $2::text[], $3::boolean[]) WITH ORDINALITY AS i(todo_id, title, done, ord)
+	`
+	_, err := tx.Exec(query, pq.Array(todoIDs), pq.Array(titles), pq.Array(done))
+	return err
+}
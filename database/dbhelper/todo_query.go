@@ -0,0 +1,123 @@
+package dbhelper
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/lib/pq"
+)
+
+// todoQueryOperators maps the comparison operators of the filter language to SQL.
+var todoQueryOperators = map[string]string{
+	"<":  "<",
+	"<=": "<=",
+	">":  ">",
+	">=": ">=",
+}
+
+// todoFilter returns the conditions restricting todos to filter together with
+// their arguments. Further parameters of a query are numbered after len(args).
+func todoFilter(userID string, filter model.TodoFilter) (string, []interface{}) {
+	conditions := todoFilterConditions
+	args := todoFilterArgs(userID, filter)
+
+	for _, term := range filter.Query {
+		condition, termArgs := todoQueryCondition(term, len(args)+1)
+		conditions += "\n\t\t  AND " + condition
+		args = append(args, termArgs...)
+	}
+	return conditions, args
+}
+
+// todoQueryCondition compiles one validated term of the filter language into a
+// condition whose values are all passed as parameters, numbered from first on.
+func todoQueryCondition(term model.TodoQueryTerm, first int) (string, []interface{}) {
+	var args []interface{}
+	param := func(value interface{}, cast string) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(first+len(args)-1) + cast
+	}
+
+	var condition string
+	switch term.Field {
+	case "status":
+		condition = "status = ANY(" + param(pq.Array(term.Values), "::status[]") + ")"
+
+	case "priority":
+		condition = "priority = ANY(" + param(pq.Array(term.Values), "::priority[]") + ")"
+
+	case "project":
+		condition = "project_id = ANY(" + param(pq.Array(term.Values), "::uuid[]") + ")"
+
+	case "tag":
+		condition = `EXISTS (
+			  SELECT 1
+			  FROM todo_tags tt
+			  JOIN tags tg ON tg.id = tt.tag_id
+			  WHERE tt.todo_id = todos.id
+			    AND LOWER(tg.name) = ANY(` + param(pq.Array(lowerAll(term.Values)), "::text[]") + `)
+		  )`
+
+	case "text":
+		pattern := "%" + likeEscaper.Replace(term.Values[0]) + "%"
+		p := param(pattern, "")
+		condition = "(title ILIKE " + p + " OR COALESCE(description, '') ILIKE " + p + ")"
+
+	case "is":
+		condition = "deadline < NOW() AND status <> 'Completed'"
+
+	case "has":
+		condition = "deadline IS NOT NULL"
+
+	case "no":
+		condition = "deadline IS NULL"
+
+	case "due", "created":
+		column := "deadline"
+		if term.Field == "created" {
+			column = "created_at"
+		}
+		condition = todoDateCondition(column, term, param)
+	}
+
+	if term.Negate {
+		// a missing deadline matches neither a term nor its negation otherwise
+		condition = "NOT COALESCE((" + condition + "), FALSE)"
+	}
+	return condition, args
+}
+
+// todoDateCondition compiles a due or created term. Dates stand for whole days,
+// so due<=2026-11-01 includes that day and due>2026-11-01 starts after it.
+func todoDateCondition(column string, term model.TodoQueryTerm, param func(interface{}, string) string) string {
+	value := term.Values[0]
+
+	if term.Op != ":" {
+		switch term.Op {
+		case "<=":
+			return column + " < " + param(value, "::date") + " + 1"
+		case ">":
+			return column + " >= " + param(value, "::date") + " + 1"
+		}
+		return column + " " + todoQueryOperators[term.Op] + " " + param(value, "::date")
+	}
+
+	switch value {
+	case "today":
+		return column + " >= CURRENT_DATE AND " + column + " < CURRENT_DATE + 1"
+	case "this-week":
+		return column + " >= date_trunc('week', CURRENT_DATE) AND " + column + " < date_trunc('week', CURRENT_DATE) + INTERVAL '7 days'"
+	case "overdue":
+		return column + " < NOW() AND status <> 'Completed'"
+	}
+
+	from, to := value, value
+	if len(term.Values) == 2 {
+		from, to = term.Values[0], term.Values[1]
+	}
+	return column + " >= " + param(from, "::date") + " AND " + column + " < " + param(to, "::date") + " + 1"
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,155 @@
+package dbhelper
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ExportTodos calls fn with every todo matching filter, oldest first, reading
+// them from the database one by one instead of all at once.
+func ExportTodos(userID string, filter model.TodoFilter, fn func(model.Todo) error) error {
+	conditions, args := todoFilter(userID, filter)
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE ` + conditions + `
+		ORDER BY created_at, id
+	`
+
+	rows, err := database.Todo.Queryx(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todo model.Todo
+		if err := rows.StructScan(&todo); err != nil {
+			return err
+		}
+		if err := fn(todo); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// importedTodo is a todo of CreateTodos in the form json_to_recordset reads.
+type importedTodo struct {
+	Ord          int               `json:"ord"`
+	ID           string            `json:"id"`
+	Title        string            `json:"title"`
+	Status       string            `json:"status"`
+	Description  string            `json:"description"`
+	Deadline     *time.Time        `json:"deadline"`
+	ProjectID    *string           `json:"project_id"`
+	AutoComplete bool              `json:"auto_complete"`
+	Recurrence   *model.Recurrence `json:"recurrence"`
+	Priority     string            `json:"priority"`
+	CompletedAt  *time.Time        `json:"completed_at"`
+}
+
+// CreateTodos creates validated todos with their tags using a few statements
+// for all of them, and returns their IDs in the order of todos. Todos in a
+// project are appended to it in that order too.
+func CreateTodos(tx *sqlx.Tx, userID string, todos []model.Todo) ([]string, error) {
+	queryIDs := `
+		SELECT gen_random_uuid()
+		FROM generate_series(1, $1)
+	`
+	todoIDs := []string{}
+	if err := tx.Select(&todoIDs, queryIDs, len(todos)); err != nil {
+		return nil, err
+	}
+
+	rows := make([]importedTodo, len(todos))
+	var tagTodoIDs, tagNames []string
+	for i, todo := range todos {
+		rows[i] = importedTodo{
+			Ord:          i,
+			ID:           todoIDs[i],
+			Title:        todo.Title,
+			Status:       todo.Status,
+			Description:  todo.Description,
+			ProjectID:    todo.ProjectID,
+			AutoComplete: todo.AutoComplete,
+			Recurrence:   todo.Recurrence,
+			Deadline:     todo.Deadline,
+			Priority:     todo.Priority,
+			CompletedAt:  todo.CompletedAt,
+		}
+		for _, tag := range todo.Tags {
+			tagTodoIDs = append(tagTodoIDs, todoIDs[i])
+			tagNames = append(tagNames, tag)
+		}
+	}
+
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	queryTodos := `
+		INSERT INTO todos (id, user_id, title, status, description, deadline, project_id, position, auto_complete,
+		                   recurrence, priority, completed_at)
+		SELECT r.id, $1, r.title, r.status, r.description, r.deadline, r.project_id,
+		       COALESCE((
+			       SELECT MAX(position) + 1
+			       FROM todos
+			       WHERE project_id = r.project_id
+		       ), 0) + ROW_NUMBER() OVER (PARTITION BY r.project_id ORDER BY r.ord) - 1,
+		       r.auto_complete, r.recurrence, COALESCE(NULLIF(r.priority, '')::priority, 'P3'),
+		       CASE WHEN r.status = 'Completed' THEN COALESCE(r.completed_at, NOW()) END
+		FROM json_to_recordset($2::json) AS r(ord INT, id UUID, title TEXT, status status, description TEXT,
+		                                      deadline TIMESTAMPTZ, project_id UUID, auto_complete BOOLEAN,
+		                                      recurrence JSONB, priority TEXT, completed_at TIMESTAMPTZ)
+	`
+	if _, err := tx.Exec(queryTodos, userID, data); err != nil {
+		return nil, err
+	}
+
+	if len(tagNames) == 0 {
+		return todoIDs, nil
+	}
+
+	queryCreateTags := `
+		INSERT INTO tags (user_id, name)
+		SELECT DISTINCT ON (LOWER(name)) $1, name
+		FROM UNNEST($2::text[]) AS name
+		ON CONFLICT (user_id, LOWER(name)) DO NOTHING
+	`
+	if _, err := tx.Exec(queryCreateTags, userID, pq.Array(tagNames)); err != nil {
+		return nil, err
+	}
+
+	queryAttachTags := `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT DISTINCT t.todo_id, tg.id
+		FROM UNNEST($2::uuid[], $3::text[]) AS t(todo_id, name)
+		JOIN tags tg ON tg.user_id = $1
+		            AND LOWER(tg.name) = LOWER(t.name)
+	`
+	if _, err := tx.Exec(queryAttachTags, userID, pq.Array(tagTodoIDs), pq.Array(tagNames)); err != nil {
+		return nil, err
+	}
+	return todoIDs, nil
+}
+
+// GetTodosTx returns the todos of the user with the given IDs, in no particular order.
+func GetTodosTx(tx *sqlx.Tx, userID string, todoIDs []string) ([]model.Todo, error) {
+	query := `
+		SELECT ` + todoColumns + `
+		FROM todos
+		WHERE user_id = $1
+		  AND id = ANY($2::uuid[])
+	`
+
+	todos := []model.Todo{}
+	err := tx.Select(&todos, query, userID, pq.Array(todoIDs))
+	return todos, err
+}
@@ -1,18 +1,21 @@
 package dbhelper
 
 import (
+	"time"
+
 	"github.com/Shubhouy1/todo-app/database"
 	"github.com/Shubhouy1/todo-app/model"
 	"github.com/jmoiron/sqlx"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// IsUserExist reports whether the email is taken, counting deleted accounts
+// until they are purged, as they may still be reactivated.
 func IsUserExist(email string) (bool, error) {
 	query := `
 		SELECT COUNT(*) > 0
 		FROM users
 		WHERE TRIM(LOWER(email)) = TRIM(LOWER($1))
-		  AND archived_at IS NULL
 	`
 
 	var exist bool
@@ -56,27 +59,28 @@ func CreateUserSessionOnLogin(userId string, sessionID int64) error {
 	return nil
 }
 
-func GetUserByEmail(tx *sqlx.Tx, email, password string) (string, error) {
+// GetUserByEmail returns the user with the email and password, deleted ones
+// that are not purged yet included; ArchivedAt tells when those were deleted.
+func GetUserByEmail(tx *sqlx.Tx, email, password string) (model.UserExist, error) {
 	query := `
-		SELECT id, password
+		SELECT id, password, archived_at
 		FROM users
 		WHERE TRIM(LOWER(email)) = TRIM(LOWER($1))
-		AND archived_at IS NULL
 	`
 	var result model.UserExist
 
 	err := tx.Get(&result, query, email)
 	if err != nil {
-		return "", err
+		return model.UserExist{}, err
 	}
 	if err := bcrypt.CompareHashAndPassword(
 		[]byte(result.Password),
 		[]byte(password),
 	); err != nil {
-		return "", err
+		return model.UserExist{}, err
 	}
 
-	return result.ID, nil
+	return result, nil
 
 }
 
@@ -118,6 +122,43 @@ func DeleteUser(tx *sqlx.Tx, userID string) error {
 	return err
 }
 
+// ReactivateUser undoes the deletion of a user that has not been purged yet,
+// bringing back the todos that were deleted along with the account. Todos that
+// were already in the trash stay there.
+func ReactivateUser(tx *sqlx.Tx, userID string) error {
+	queryTodos := `
+		UPDATE todos t
+		SET archived_at = NULL, version = t.version + 1
+		FROM users u
+		WHERE u.id = $1
+		  AND t.user_id = u.id
+		  AND t.archived_at = u.archived_at
+	`
+	if _, err := tx.Exec(queryTodos, userID); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE users
+		SET archived_at = NULL
+		WHERE id = $1
+	`
+	_, err := tx.Exec(query, userID)
+	return err
+}
+
+// PurgeDeletedUsers hard-deletes every user deleted before cutoff, with all
+// their data, and returns how many were removed. Their emails can be used again.
+func PurgeDeletedUsers(cutoff time.Time) (int64, error) {
+	query := `DELETE FROM users WHERE archived_at < $1`
+
+	result, err := database.Todo.Exec(query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func DeleteUserSessionsByUserID(tx *sqlx.Tx, userID string) error {
 	query := `
 		UPDATE user_sessions
@@ -0,0 +1,304 @@
+package dbhelper
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func CreateWebhook(userID, url, secret string, events []string) (string, error) {
+	query := `
+		INSERT INTO webhooks (user_id, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	var webhookID string
+	err := database.Todo.Get(&webhookID, query, userID, url, secret, pq.Array(events))
+	if err != nil {
+		return "", err
+	}
+	return webhookID, nil
+}
+
+func GetWebhooks(userID string) ([]model.Webhook, error) {
+	query := `
+		SELECT id, url, secret, events, active, created_at
+		FROM webhooks
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	webhooks := []model.Webhook{}
+	err := database.Todo.Select(&webhooks, query, userID)
+	return webhooks, err
+}
+
+func GetWebhookByID(userID, webhookID string) (*model.Webhook, error) {
+	var webhook model.Webhook
+
+	query := `
+		SELECT id, url, secret, events, active, created_at
+		FROM webhooks
+		WHERE id = $1
+		  AND user_id = $2
+	`
+
+	err := database.Todo.Get(&webhook, query, webhookID, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// UpdateWebhook changes the URL and events of a webhook, and its active flag unless nil.
+func UpdateWebhook(userID, webhookID, url string, events []string, active *bool) error {
+	query := `
+		UPDATE webhooks
+		SET url = $1, events = $2, active = COALESCE($3, active)
+		WHERE id = $4
+		  AND user_id = $5
+	`
+	_, err := database.Todo.Exec(query, url, pq.Array(events), active, webhookID, userID)
+	return err
+}
+
+func DeleteWebhook(userID, webhookID string) error {
+	query := `
+		DELETE FROM webhooks
+		WHERE id = $1
+		  AND user_id = $2
+	`
+	_, err := database.Todo.Exec(query, webhookID, userID)
+	return err
+}
+
+// CreateOutboxEvent stores a todo event in the outbox, in the transaction of
+// the change it describes.
+func CreateOutboxEvent(tx *sqlx.Tx, userID, todoID, event string, payload model.TodoEvent) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO outbox_events (user_id, todo_id, event, payload)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.Exec(query, userID, todoID, event, data)
+	return err
+}
+
+// CreateOutboxEvents stores the same event for many todos of a user at once,
+// payloads[i] being the one of todoIDs[i], keeping their order.
+func CreateOutboxEvents(tx *sqlx.Tx, userID, event string, todoIDs []string, payloads []model.TodoEvent) error {
+	data := make([]string, len(payloads))
+	for i := range payloads {
+		encoded, err := json.Marshal(payloads[i])
+		if err != nil {
+			return err
+		}
+		data[i] = string(encoded)
+	}
+
+	query := `
+		INSERT INTO outbox_events (user_id, todo_id, event, payload)
+		SELECT $1, e.todo_id, $2, e.payload::jsonb
+		FROM UNNEST($3::uuid[], $4::text[]) WITH ORDINALITY AS e(todo_id, payload, ord)
+		ORDER BY e.ord
+	`
+	_, err := tx.Exec(query, userID, event, pq.Array(todoIDs), pq.Array(data))
+	return err
+}
+
+// DispatchOutboxEvents queues a delivery to every active webhook subscribed to
+// each undispatched outbox event, at most limit events at a time, and returns
+// how many events it dispatched.
+func DispatchOutboxEvents(tx *sqlx.Tx, limit int) (int64, error) {
+	query := `
+		WITH events AS (
+			SELECT id, user_id, event
+			FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), queued AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id)
+			SELECT w.id, e.id
+			FROM events e
+			JOIN webhooks w ON w.user_id = e.user_id
+			WHERE w.active
+			  AND e.event = ANY(w.events)
+		)
+		UPDATE outbox_events
+		SET dispatched_at = NOW()
+		WHERE id IN (SELECT id FROM events)
+	`
+
+	result, err := tx.Exec(query, limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ClaimWebhookDelivery takes the oldest delivery that is due, skipping the ones
+// other replicas are taking at the same time, and moves its next attempt ahead
+// by lease. The delivery can then be sent outside of any transaction; should the
+// outcome never be recorded, it is attempted again once the lease is over.
+func ClaimWebhookDelivery(lease time.Duration) (*model.PendingDelivery, error) {
+	var delivery model.PendingDelivery
+
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending'
+			  AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d
+			SET next_attempt_at = NOW() + make_interval(secs => $1)
+			FROM due
+			WHERE d.id = due.id
+			RETURNING d.id, d.attempts, d.webhook_id, d.event_id
+		)
+		SELECT c.id, c.attempts, w.url, w.secret, e.id AS event_id, e.event, e.payload,
+		       e.created_at AS event_created_at
+		FROM claimed c
+		JOIN webhooks w ON w.id = c.webhook_id
+		JOIN outbox_events e ON e.id = c.event_id
+	`
+
+	err := database.Todo.Get(&delivery, query, lease.Seconds())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+// UpdateWebhookDelivery records the outcome of an attempt. A pending delivery
+// is attempted again at nextAttemptAt.
+func UpdateWebhookDelivery(
+	deliveryID int64,
+	status string,
+	responseStatus *int,
+	errMsg *string,
+	nextAttemptAt *time.Time,
+) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1,
+		    attempts = attempts + 1,
+		    response_status = $2,
+		    error = $3,
+		    next_attempt_at = $4,
+		    delivered_at = CASE WHEN $1 = 'succeeded' THEN NOW() END
+		WHERE id = $5
+	`
+	_, err := database.Todo.Exec(query, status, responseStatus, errMsg, nextAttemptAt, deliveryID)
+	return err
+}
+
+// GetWebhookDeliveries returns the deliveries of a webhook, newest first.
+func GetWebhookDeliveries(webhookID, userID string, limit, offset int) ([]model.WebhookDelivery, error) {
+	query := `
+		SELECT d.id, d.event_id, e.event, d.status, d.attempts, d.response_status, d.error,
+		       CASE WHEN d.status = 'pending' THEN d.next_attempt_at END AS next_attempt_at,
+		       d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		JOIN outbox_events e ON e.id = d.event_id
+		WHERE d.webhook_id = $1
+		  AND w.user_id = $2
+		ORDER BY d.id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	deliveries := []model.WebhookDelivery{}
+	err := database.Todo.Select(&deliveries, query, webhookID, userID, limit, offset)
+	return deliveries, err
+}
+
+// GetTodoEventCursor returns where a new stream of todo events starts: after
+// the events of every transaction that has ended, and before the rest.
+func GetTodoEventCursor() (model.TodoEventCursor, error) {
+	query := `SELECT pg_snapshot_xmin(pg_current_snapshot())::text::bigint`
+
+	var cursor model.TodoEventCursor
+	err := database.Todo.Get(&cursor.XID, query)
+	return cursor, err
+}
+
+// GetTodoEventsAfter returns at most limit todo events of a user after cursor,
+// in stream order. Only the events of transactions older than every running
+// one are returned, as a running transaction may still add an event before
+// them; pending tells whether events were held back for that reason.
+func GetTodoEventsAfter(userID string, cursor model.TodoEventCursor, limit int) ([]model.OutboxEvent, bool, error) {
+	query := `
+		SELECT id, xid::text::bigint AS xid, todo_id, event, payload, created_at,
+		       xid < pg_snapshot_xmin(pg_current_snapshot()) AS settled
+		FROM outbox_events
+		WHERE user_id = $1
+		  AND (xid, id) > ($2::text::xid8, $3)
+		ORDER BY xid, id
+		LIMIT $4
+	`
+
+	var rows []struct {
+		model.OutboxEvent
+		Settled bool `db:"settled"`
+	}
+	if err := database.Todo.Select(&rows, query, userID, cursor.XID, cursor.ID, limit); err != nil {
+		return nil, false, err
+	}
+
+	events := make([]model.OutboxEvent, 0, len(rows))
+	for _, row := range rows {
+		// rows are in xid order, so the unsettled ones come last
+		if !row.Settled {
+			return events, true, nil
+		}
+		row.Cursor = model.TodoEventCursor{XID: row.XID, ID: row.ID}.String()
+		events = append(events, row.OutboxEvent)
+	}
+	return events, false, nil
+}
+
+// PurgeDispatchedOutboxEvents deletes the events dispatched before cutoff that
+// have no delivery left to attempt, with their delivery log, and returns how
+// many were removed.
+func PurgeDispatchedOutboxEvents(cutoff time.Time) (int64, error) {
+	query := `
+		DELETE FROM outbox_events e
+		WHERE e.dispatched_at < $1
+		  AND NOT EXISTS (
+			  SELECT 1
+			  FROM webhook_deliveries d
+			  WHERE d.event_id = e.id
+			    AND d.status = 'pending'
+		  )
+	`
+
+	result, err := database.Todo.Exec(query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS tags
+(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tags_user_name_unique_idx
+    ON tags (user_id, LOWER(name));
+
+CREATE TABLE IF NOT EXISTS todo_tags
+(
+    todo_id UUID NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    tag_id  UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS todo_tags_tag_id_idx
+    ON todo_tags (tag_id);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS projects
+(
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name        TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ DEFAULT NOW(),
+    archived_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS projects_user_id_idx
+    ON projects (user_id) WHERE archived_at IS NULL;
+
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS position   INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS todos_project_id_position_idx
+    ON todos (project_id, position) WHERE archived_at IS NULL;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS todo_items
+(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    todo_id    UUID    NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    title      TEXT    NOT NULL,
+    done       BOOLEAN NOT NULL DEFAULT FALSE,
+    position   INT     NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS todo_items_todo_id_position_idx
+    ON todo_items (todo_id, position);
+
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS auto_complete BOOLEAN NOT NULL DEFAULT FALSE;
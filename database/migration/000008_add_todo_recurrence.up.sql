@@ -0,0 +1,10 @@
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS recurrence  JSONB,
+    ADD COLUMN IF NOT EXISTS series_id   UUID,
+    ADD COLUMN IF NOT EXISTS previous_id UUID REFERENCES todos (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS todos_series_id_idx
+    ON todos (series_id) WHERE series_id IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS todos_previous_id_unique_idx
+    ON todos (previous_id) WHERE previous_id IS NOT NULL;
@@ -0,0 +1,10 @@
+-- values are declared from lowest to highest, so ORDER BY priority DESC puts P0 first
+CREATE TYPE priority AS ENUM (
+    'P3',
+    'P2',
+    'P1',
+    'P0'
+    );
+
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS priority priority NOT NULL DEFAULT 'P3';
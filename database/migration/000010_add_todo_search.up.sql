@@ -0,0 +1,9 @@
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+        GENERATED ALWAYS AS (
+            setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+            setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+            ) STORED;
+
+CREATE INDEX IF NOT EXISTS todos_search_vector_idx
+    ON todos USING GIN (search_vector);
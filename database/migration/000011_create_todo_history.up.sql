@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS todo_history
+(
+    id         BIGSERIAL PRIMARY KEY,
+    todo_id    UUID  NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    user_id    UUID  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    session_id BIGINT,
+    action     TEXT  NOT NULL CHECK (action IN ('created', 'updated', 'status_changed', 'deleted', 'restored')),
+    changes    JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS todo_history_todo_id_idx
+    ON todo_history (todo_id, created_at);
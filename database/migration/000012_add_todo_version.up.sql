@@ -0,0 +1,2 @@
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+-- todos created without a deadline used to store the zero time instead of NULL
+UPDATE todos
+SET deadline = NULL
+WHERE deadline < '1000-01-01';
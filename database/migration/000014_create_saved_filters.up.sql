@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS saved_filters
+(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name       TEXT NOT NULL,
+    filter     TEXT NOT NULL DEFAULT '',
+    sort       TEXT NOT NULL DEFAULT '',
+    page_size  INT  NOT NULL DEFAULT 10,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS saved_filters_user_name_unique_idx
+    ON saved_filters (user_id, LOWER(name));
@@ -0,0 +1,24 @@
+CREATE TYPE late_completion AS ENUM (
+    'forbid',
+    'allow',
+    'allow_and_flag'
+);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS late_completion late_completion NOT NULL DEFAULT 'forbid';
+
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS completed_at   TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS completed_late BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- completed todos take the time of their last completion from the audit trail
+UPDATE todos t
+SET completed_at = h.created_at
+FROM (
+    SELECT DISTINCT ON (todo_id) todo_id, created_at
+    FROM todo_history
+    WHERE changes -> 'status' ->> 'new' = 'Completed'
+    ORDER BY todo_id, created_at DESC
+) h
+WHERE h.todo_id = t.id
+  AND t.status = 'Completed';
@@ -0,0 +1 @@
+ALTER TYPE status ADD VALUE IF NOT EXISTS 'Overdue';
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS todo_reminders
+(
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    todo_id        UUID NOT NULL REFERENCES todos (id) ON DELETE CASCADE,
+    offset_minutes INT  NOT NULL CHECK (offset_minutes > 0),
+    -- the deadline the reminder has been delivered, or given up, for
+    done_for       TIMESTAMP,
+    done_at        TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (todo_id, offset_minutes)
+);
+
+CREATE TABLE IF NOT EXISTS reminder_deliveries
+(
+    id           BIGSERIAL PRIMARY KEY,
+    reminder_id  UUID      NOT NULL REFERENCES todo_reminders (id) ON DELETE CASCADE,
+    deadline     TIMESTAMP NOT NULL,
+    notifier     TEXT      NOT NULL,
+    success      BOOLEAN   NOT NULL,
+    error        TEXT,
+    attempted_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS reminder_deliveries_reminder_id_idx
+    ON reminder_deliveries (reminder_id, deadline);
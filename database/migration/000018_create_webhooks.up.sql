@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID    NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    url        TEXT    NOT NULL,
+    secret     TEXT    NOT NULL,
+    events     TEXT[]  NOT NULL,
+    active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_user_id_idx
+    ON webhooks (user_id);
+
+-- todo events are written here in the transaction of the change and fanned out
+-- to the webhooks of the user afterwards
+CREATE TABLE IF NOT EXISTS outbox_events
+(
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       UUID  NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    todo_id       UUID  NOT NULL,
+    event         TEXT  NOT NULL,
+    payload       JSONB NOT NULL,
+    created_at    TIMESTAMPTZ DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_events_undispatched_idx
+    ON outbox_events (id) WHERE dispatched_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id              BIGSERIAL PRIMARY KEY,
+    webhook_id      UUID   NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id        BIGINT NOT NULL REFERENCES outbox_events (id) ON DELETE CASCADE,
+    status          TEXT   NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts        INT    NOT NULL DEFAULT 0,
+    response_status INT,
+    error           TEXT,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+    created_at      TIMESTAMPTZ DEFAULT NOW(),
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx
+    ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx
+    ON webhook_deliveries (webhook_id, created_at);
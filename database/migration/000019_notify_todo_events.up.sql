@@ -0,0 +1,20 @@
+-- every todo event is announced on the todo_events channel once its transaction
+-- commits; listeners read the event itself from outbox_events
+CREATE OR REPLACE FUNCTION notify_todo_event() RETURNS trigger AS
+$$
+BEGIN
+    PERFORM pg_notify('todo_events', json_build_object('id', NEW.id, 'user_id', NEW.user_id)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS outbox_events_notify ON outbox_events;
+
+CREATE TRIGGER outbox_events_notify
+    AFTER INSERT ON outbox_events
+    FOR EACH ROW
+EXECUTE FUNCTION notify_todo_event();
+
+-- streams resume from the last event a client saw
+CREATE INDEX IF NOT EXISTS outbox_events_user_id_idx
+    ON outbox_events (user_id, id);
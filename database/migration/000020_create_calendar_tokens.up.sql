@@ -0,0 +1,15 @@
+-- calendar apps cannot send a bearer token, so the feed of a user is read with
+-- a secret token in its URL; only the SHA-256 hash of the token is stored
+CREATE TABLE IF NOT EXISTS calendar_tokens
+(
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash   TEXT NOT NULL UNIQUE,
+    created_at   TIMESTAMPTZ DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+-- rotating a token revokes the previous one, so a user has one in use at most
+CREATE UNIQUE INDEX IF NOT EXISTS calendar_tokens_active_idx
+    ON calendar_tokens (user_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,19 @@
+-- a ZIP of all the data of a user, built by a job after it is requested and
+-- kept in the database, so any replica can serve it, until it expires
+CREATE TABLE IF NOT EXISTS account_exports
+(
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status      TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    archive     BYTEA,
+    size        BIGINT,
+    created_at  TIMESTAMPTZ DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    expires_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS account_exports_pending_idx
+    ON account_exports (created_at) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS account_exports_user_id_idx
+    ON account_exports (user_id, created_at);
@@ -0,0 +1,15 @@
+-- ids are taken before the transaction writing an event commits, so a stream
+-- reading by id may pass an event that commits later with a lower one; streams
+-- read in the order of the writing transactions instead, and only the events
+-- of transactions that have ended
+ALTER TABLE outbox_events
+    ADD COLUMN IF NOT EXISTS xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+DROP INDEX IF EXISTS outbox_events_user_id_idx;
+
+CREATE INDEX IF NOT EXISTS outbox_events_user_xid_idx
+    ON outbox_events (user_id, xid, id);
+
+-- dispatched events are pruned after a while
+CREATE INDEX IF NOT EXISTS outbox_events_dispatched_at_idx
+    ON outbox_events (dispatched_at) WHERE dispatched_at IS NOT NULL;
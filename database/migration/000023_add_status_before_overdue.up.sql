@@ -0,0 +1,16 @@
+-- the status an Overdue todo had, restored once its deadline is moved into the future
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS status_before_overdue status;
+
+-- the audit trail tells what todos overdue already were before
+UPDATE todos t
+SET status_before_overdue = (h.changes -> 'status' ->> 'old')::status
+FROM (
+    SELECT DISTINCT ON (todo_id) todo_id, changes
+    FROM todo_history
+    WHERE changes -> 'status' ->> 'new' = 'Overdue'
+    ORDER BY todo_id, created_at DESC
+) h
+WHERE h.todo_id = t.id
+  AND t.status = 'Overdue'
+  AND h.changes -> 'status' ->> 'old' IN ('Pending', 'Not Completed');
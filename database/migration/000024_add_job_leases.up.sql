@@ -0,0 +1,8 @@
+-- jobs claim a reminder or an account export for a while and do their slow
+-- work outside of any transaction; until the lease ends no other replica
+-- takes it, and once it ends, e.g. because the replica died, it is taken again
+ALTER TABLE todo_reminders
+    ADD COLUMN IF NOT EXISTS leased_until TIMESTAMPTZ;
+
+ALTER TABLE account_exports
+    ADD COLUMN IF NOT EXISTS leased_until TIMESTAMPTZ;
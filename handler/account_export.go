@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// RequestAccountExport asks for a ZIP of everything held about the user: the
+// profile, settings, all todos including deleted ones, projects, tags, saved
+// filters, webhooks, sessions and history. It is built in the background and
+// downloaded from GET /me/export/{id}. While an export is being built, asking
+// again returns that one.
+func RequestAccountExport(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	export, err := dbhelper.GetPendingAccountExport(auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch export")
+		return
+	}
+	if export == nil {
+		created, err := dbhelper.CreateAccountExport(auth.UserID)
+		if err != nil {
+			util.RespondError(w, http.StatusInternalServerError, err, "failed to request export")
+			return
+		}
+		export = &created
+	}
+
+	w.Header().Set("Location", "/me/export/"+export.ID)
+	util.RespondJSON(w, http.StatusAccepted, export)
+}
+
+// GetAccountExport downloads the ZIP of a ready export. An export still being
+// built is answered with 202 and a failed one with 200, both with the export
+// itself, so a client polls until it gets the file.
+func GetAccountExport(w http.ResponseWriter, r *http.Request) {
+	exportID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(exportID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "export not found")
+		return
+	}
+
+	export, err := dbhelper.GetAccountExport(auth.UserID, exportID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch export")
+		return
+	}
+	if export == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "export not found")
+		return
+	}
+
+	switch export.Status {
+	case model.AccountExportPending:
+		util.RespondJSON(w, http.StatusAccepted, export)
+		return
+	case model.AccountExportFailed:
+		util.RespondJSON(w, http.StatusOK, export)
+		return
+	}
+
+	archive, err := dbhelper.GetAccountExportArchive(auth.UserID, exportID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch export")
+		return
+	}
+
+	filename := "todo-app-export-" + export.CreatedAt.UTC().Format("2006-01-02") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(archive)
+}
@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	bulkItemOK         = "ok"
+	bulkItemSkipped    = "skipped"
+	bulkItemFailed     = "failed"
+	bulkItemRolledBack = "rolled_back"
+
+	// maxBulkTodos is how many todos one bulk request may change, the same
+	// whether they are picked by ids or by filter.
+	maxBulkTodos = 500
+)
+
+var errBulkTooLarge = fmt.Errorf("filter matches more than %d todos", maxBulkTodos)
+
+// bulkSkip marks a todo the bulk action does not apply to. It is reported
+// for that todo but, unlike a failure, never rolls an atomic operation back.
+type bulkSkip string
+
+func (s bulkSkip) Error() string {
+	return string(s)
+}
+
+// BulkTodos applies one action to many todos inside a single transaction and
+// reports the outcome for each of them. Each todo goes through a savepoint, so
+// a failing todo only undoes its own change unless the request is atomic.
+func BulkTodos(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	var body model.BulkRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	if (len(body.IDs) == 0) == (body.Filter == "") {
+		util.RespondError(w, http.StatusBadRequest, nil, "either ids or filter is required")
+		return
+	}
+
+	var filter model.TodoFilter
+	if body.Filter != "" {
+		if body.Action == model.BulkRestore {
+			util.RespondError(w, http.StatusBadRequest, nil, "restore needs ids")
+			return
+		}
+
+		query, err := url.ParseQuery(body.Filter)
+		if err != nil {
+			util.RespondError(w, http.StatusBadRequest, err, "invalid filter")
+			return
+		}
+		filter, err = parseTodoFilter(query)
+		if err != nil {
+			util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		filter.Query, err = parseTodoQuery(query.Get("q"))
+		if err != nil {
+			util.RespondError(w, http.StatusBadRequest, nil, "invalid q: "+err.Error())
+			return
+		}
+	}
+
+	if body.Action == model.BulkMoveToProject && !checkProject(w, userID, body.ProjectID) {
+		return
+	}
+
+	var results []model.BulkResult
+	itemFailed := false
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		todoIDs := body.IDs
+		if body.Filter != "" {
+			var err error
+			todoIDs, err = dbhelper.GetTodoIDs(tx, userID, filter, maxBulkTodos+1)
+			if err != nil {
+				return err
+			}
+			if len(todoIDs) > maxBulkTodos {
+				return errBulkTooLarge
+			}
+		}
+
+		results = make([]model.BulkResult, 0, len(todoIDs))
+		for _, todoID := range todoIDs {
+			if !body.Atomic {
+				if _, err := tx.Exec("SAVEPOINT bulk_item"); err != nil {
+					return err
+				}
+			}
+
+			result := model.BulkResult{ID: todoID, Status: bulkItemOK}
+			err := applyBulkAction(tx, auth, todoID, body)
+
+			var skip bulkSkip
+			switch {
+			case errors.As(err, &skip):
+				result.Status = bulkItemSkipped
+				result.Error = skip.Error()
+			case err != nil:
+				result.Status = bulkItemFailed
+				result.Error = err.Error()
+			}
+			results = append(results, result)
+
+			if body.Atomic {
+				if result.Status == bulkItemFailed {
+					itemFailed = true
+					return err
+				}
+				continue
+			}
+
+			savepoint := "RELEASE SAVEPOINT bulk_item"
+			if result.Status == bulkItemFailed {
+				savepoint = "ROLLBACK TO SAVEPOINT bulk_item"
+			}
+			if _, err := tx.Exec(savepoint); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if errors.Is(txErr, errBulkTooLarge) {
+		util.RespondError(w, http.StatusUnprocessableEntity, nil, txErr.Error())
+		return
+	}
+	if txErr != nil && !itemFailed {
+		util.RespondError(w, http.StatusInternalServerError, txErr, "failed to apply bulk action")
+		return
+	}
+
+	if itemFailed {
+		for i := range results {
+			if results[i].Status == bulkItemOK {
+				results[i].Status = bulkItemRolledBack
+			}
+		}
+		util.RespondJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+			"atomic":  true,
+			"results": results,
+		})
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"atomic":  body.Atomic,
+		"results": results,
+	})
+}
+
+// applyBulkAction applies the action of a bulk request to one todo, through
+// the same paths, history included, as the single-todo endpoints.
+func applyBulkAction(tx *sqlx.Tx, auth middleware.AuthContext, todoID string, body model.BulkRequest) error {
+	userID := auth.UserID
+
+	if body.Action == model.BulkRestore {
+		return withTodoHistory(tx, auth, todoID, model.HistoryRestored, func() error {
+			restored, err := dbhelper.RestoreTodo(tx, userID, todoID)
+			if err == nil && !restored {
+				return errors.New("todo not found in trash")
+			}
+			return err
+		})
+	}
+
+	if err := checkTodoVersion(tx, userID, todoID, ""); err != nil {
+		return err
+	}
+	todo, err := dbhelper.GetTodoTx(tx, todoID, userID)
+	if err != nil {
+		return err
+	}
+	updated := *todo
+
+	switch body.Action {
+	case model.BulkSetStatus:
+		if todo.Status == body.Status {
+			return bulkSkip("status is already " + body.Status)
+		}
+		return applyTodoStatus(tx, auth, *todo, body.Status)
+
+	case model.BulkArchive:
+		return withTodoHistory(tx, auth, todoID, model.HistoryDeleted, func() error {
+			deleted, err := dbhelper.DeleteTodo(tx, userID, todoID)
+			if err == nil && !deleted {
+				return errTodoNotFound
+			}
+			return err
+		})
+
+	case model.BulkMoveToProject:
+		updated.ProjectID = body.ProjectID
+
+	case model.BulkShiftDeadline:
+		if todo.Deadline == nil {
+			return bulkSkip("todo has no deadline")
+		}
+		deadline := todo.Deadline.AddDate(0, 0, body.ShiftDays)
+		updated.Deadline = &deadline
+
+	case model.BulkAddTag, model.BulkRemoveTag:
+		tag := strings.TrimSpace(body.Tag)
+		tags := make([]string, 0, len(todo.Tags)+1)
+		for _, existing := range todo.Tags {
+			if !strings.EqualFold(existing, tag) {
+				tags = append(tags, existing)
+			}
+		}
+		if body.Action == model.BulkAddTag {
+			tags = append(tags, tag)
+		}
+		return withTodoHistory(tx, auth, todoID, model.HistoryUpdated, func() error {
+			return dbhelper.SetTodoTags(tx, userID, todoID, normalizeTags(tags))
+		})
+	}
+
+	return withTodoHistory(tx, auth, todoID, model.HistoryUpdated, func() error {
+		return dbhelper.UpdateTodoData(tx, userID, todoID, updated)
+	})
+}
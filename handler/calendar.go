@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func GetCalendarToken(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	token, err := dbhelper.GetCalendarToken(auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch calendar token")
+		return
+	}
+	if token == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "calendar token not found")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, token)
+}
+
+// RotateCalendarToken creates the token of the calendar feed of the user,
+// revoking the previous one, and shows it this once.
+func RotateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	secret, err := util.RandomSecret(32)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to generate calendar token")
+		return
+	}
+
+	var token model.CalendarToken
+	err = database.Tx(func(tx *sqlx.Tx) error {
+		var err error
+		token, err = dbhelper.RotateCalendarToken(tx, auth.UserID, hashCalendarToken(secret))
+		return err
+	})
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to create calendar token")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":         token.ID,
+		"token":      secret,
+		"url":        "/calendar/" + secret + ".ics",
+		"created_at": token.CreatedAt,
+	})
+}
+
+func RevokeCalendarToken(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	revoked := false
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var err error
+		revoked, err = dbhelper.RevokeCalendarToken(tx, auth.UserID)
+		return err
+	})
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to revoke calendar token")
+		return
+	}
+	if !revoked {
+		util.RespondError(w, http.StatusNotFound, nil, "calendar token not found")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "revoked successfully")
+}
+
+// GetCalendarFeed serves the todos with a deadline of the owner of the token
+// as an iCalendar feed, as events unless ?component=vtodo asks for todos. It
+// is authenticated by the token alone, for calendar apps to subscribe to.
+func GetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	component := util.CalendarEvent
+	switch strings.ToUpper(r.URL.Query().Get("component")) {
+	case "", util.CalendarEvent:
+	case util.CalendarTodo:
+		component = util.CalendarTodo
+	default:
+		util.RespondError(w, http.StatusBadRequest, nil, "component must be vevent or vtodo")
+		return
+	}
+
+	userID, err := dbhelper.UseCalendarToken(hashCalendarToken(token))
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch calendar")
+		return
+	}
+	if userID == "" {
+		util.RespondError(w, http.StatusNotFound, nil, "calendar not found")
+		return
+	}
+
+	todos, err := dbhelper.GetCalendarTodos(userID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch todos")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="todos.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(util.Calendar(todos, component, time.Now()))
+}
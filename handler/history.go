@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// historyFields are the JSON names of the todo fields tracked by the audit trail.
+var historyFields = []string{
+	"title",
+	"description",
+	"status",
+	"priority",
+	"deadline",
+	"project_id",
+	"position",
+	"tags",
+	"items",
+	"auto_complete",
+	"recurrence",
+	"archived_at",
+	"completed_late",
+}
+
+// diffTodos returns the tracked fields whose value differs between before and after.
+// A nil todo counts as having no fields at all.
+func diffTodos(before, after *model.Todo) (map[string]model.FieldChange, error) {
+	oldFields, err := todoFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := todoFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]model.FieldChange{}
+	for _, field := range historyFields {
+		if !reflect.DeepEqual(oldFields[field], newFields[field]) {
+			changes[field] = model.FieldChange{Old: oldFields[field], New: newFields[field]}
+		}
+	}
+	return changes, nil
+}
+
+func todoFields(todo *model.Todo) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if todo == nil {
+		return fields, nil
+	}
+
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// recordTodoHistory stores the difference between two snapshots of a todo,
+// skipping updates that did not change any tracked field. The change is also
+// written to the outbox as a todo event for the user's webhooks.
+func recordTodoHistory(tx *sqlx.Tx, auth middleware.AuthContext, action string, before, after *model.Todo) error {
+	changes, err := diffTodos(before, after)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 && action == model.HistoryUpdated {
+		return nil
+	}
+
+	todo := after
+	if todo == nil {
+		todo = before
+	}
+
+	if err := dbhelper.CreateTodoHistory(tx, todo.ID, auth.UserID, historySessionID(auth), action, changes); err != nil {
+		return err
+	}
+
+	event := model.TodoEvent{Todo: todo, Changes: changes}
+	return dbhelper.CreateOutboxEvent(tx, auth.UserID, todo.ID, "todo."+action, event)
+}
+
+// recordTodosCreated records the creation of many todos like recordTodoHistory
+// does for one, with a single insert into the history and one into the outbox.
+func recordTodosCreated(tx *sqlx.Tx, auth middleware.AuthContext, todos []model.Todo) error {
+	todoIDs := make([]string, len(todos))
+	changes := make([]map[string]model.FieldChange, len(todos))
+	events := make([]model.TodoEvent, len(todos))
+	for i := range todos {
+		var err error
+		changes[i], err = diffTodos(nil, &todos[i])
+		if err != nil {
+			return err
+		}
+		todoIDs[i] = todos[i].ID
+		events[i] = model.TodoEvent{Todo: &todos[i], Changes: changes[i]}
+	}
+
+	if err := dbhelper.CreateTodoHistories(tx, auth.UserID, historySessionID(auth), model.HistoryCreated, todoIDs, changes); err != nil {
+		return err
+	}
+	return dbhelper.CreateOutboxEvents(tx, auth.UserID, "todo."+model.HistoryCreated, todoIDs, events)
+}
+
+// historySessionID is the session a change is recorded for; changes made by
+// the server itself have none.
+func historySessionID(auth middleware.AuthContext) *int64 {
+	if auth.SessionID == 0 {
+		return nil
+	}
+	return &auth.SessionID
+}
+
+// withTodoHistory runs change inside tx and records what it did to the todo.
+// Nothing is recorded for a todo that does not exist.
+func withTodoHistory(tx *sqlx.Tx, auth middleware.AuthContext, todoID, action string, change func() error) error {
+	before, err := dbhelper.GetTodoTx(tx, todoID, auth.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	after, err := dbhelper.GetTodoTx(tx, todoID, auth.UserID)
+	if err != nil || after == nil {
+		return err
+	}
+
+	return recordTodoHistory(tx, auth, action, before, after)
+}
+
+// withChecklistHistory runs change, a change to the checklist of a todo, inside
+// tx and records it as an update of the todo's items. Reordering counts too.
+func withChecklistHistory(tx *sqlx.Tx, auth middleware.AuthContext, todoID string, change func() error) error {
+	before, err := getTodoWithItems(tx, auth.UserID, todoID)
+	if err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	after, err := getTodoWithItems(tx, auth.UserID, todoID)
+	if err != nil || before == nil || after == nil {
+		return err
+	}
+
+	return recordTodoHistory(tx, auth, model.HistoryUpdated, before, after)
+}
+
+func getTodoWithItems(tx *sqlx.Tx, userID, todoID string) (*model.Todo, error) {
+	todo, err := dbhelper.GetTodoTx(tx, todoID, userID)
+	if err != nil || todo == nil {
+		return todo, err
+	}
+	todo.Items, err = dbhelper.GetTodoItemsTx(tx, todoID)
+	return todo, err
+}
+
+func GetTodoHistory(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(todoID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	history, err := dbhelper.GetTodoHistory(todoID, auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch history")
+		return
+	}
+	if len(history) == 0 {
+		// todos created before the audit trail existed have no entries yet
+		todo, err := dbhelper.GetTodoByID(todoID, auth.UserID)
+		if err != nil {
+			util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch todo")
+			return
+		}
+		if todo == nil {
+			util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+			return
+		}
+	}
+
+	util.RespondJSON(w, http.StatusOK, history)
+}
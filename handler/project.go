@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// checkProject reports whether projectID is empty or one of the user's active projects,
+// responding with an error otherwise.
+func checkProject(w http.ResponseWriter, userID string, projectID *string) bool {
+	status, message, err := lookupProject(userID, projectID)
+	if status != 0 {
+		util.RespondError(w, status, err, message)
+		return false
+	}
+	return true
+}
+
+// lookupProject checks that projectID, unless nil, is a project of the user.
+// When it is not, it returns the status and message to respond with.
+func lookupProject(userID string, projectID *string) (int, string, error) {
+	if projectID == nil {
+		return 0, "", nil
+	}
+
+	if err := validate.Var(*projectID, "uuid"); err != nil {
+		return http.StatusBadRequest, "invalid project_id", err
+	}
+
+	project, err := dbhelper.GetProjectByID(userID, *projectID)
+	if err != nil {
+		return http.StatusInternalServerError, "failed to fetch project", err
+	}
+	if project == nil {
+		return http.StatusBadRequest, "project not found", nil
+	}
+	return 0, "", nil
+}
+
+func CreateProject(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	var body model.ProjectRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	projectID, err := dbhelper.CreateProject(auth.UserID, body.Name, body.Description)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to create project")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusCreated, map[string]string{
+		"id": projectID,
+	})
+}
+
+func GetProjects(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	projects, err := dbhelper.GetProjects(auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch projects")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, projects)
+}
+
+func GetProjectByID(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(projectID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "project not found")
+		return
+	}
+
+	project, err := dbhelper.GetProjectByID(auth.UserID, projectID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch project")
+		return
+	}
+	if project == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "project not found")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, project)
+}
+
+func UpdateProject(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(projectID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "project not found")
+		return
+	}
+
+	userID := auth.UserID
+
+	var body model.ProjectRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	project, err := dbhelper.GetProjectByID(userID, projectID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch project")
+		return
+	}
+	if project == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "project not found")
+		return
+	}
+
+	if err := dbhelper.UpdateProject(userID, projectID, body.Name, body.Description); err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to update project")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "updated successfully")
+}
+
+// DeleteProject archives the project together with all of its todos.
+func DeleteProject(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(projectID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "project not found")
+		return
+	}
+
+	userID := auth.UserID
+
+	project, err := dbhelper.GetProjectByID(userID, projectID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch project")
+		return
+	}
+	if project == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "project not found")
+		return
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		todoIDs, err := dbhelper.DeleteProjectTodos(tx, userID, projectID)
+		if err != nil {
+			return err
+		}
+
+		for _, todoID := range todoIDs {
+			archived, err := dbhelper.GetTodoTx(tx, todoID, userID)
+			if err != nil {
+				return err
+			}
+			active := *archived
+			active.ArchivedAt = nil
+			if err := recordTodoHistory(tx, auth, model.HistoryDeleted, &active, archived); err != nil {
+				return err
+			}
+		}
+
+		return dbhelper.ArchiveProject(tx, userID, projectID)
+	})
+	if txErr != nil {
+		util.RespondError(w, http.StatusInternalServerError, txErr, "failed to archive project")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "archived successfully")
+}
+
+func ReorderProjectTodos(w http.ResponseWriter, r *http.Request) {
+	projectID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(projectID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "project not found")
+		return
+	}
+
+	userID := auth.UserID
+
+	var body model.ProjectOrderRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	project, err := dbhelper.GetProjectByID(userID, projectID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch project")
+		return
+	}
+	if project == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "project not found")
+		return
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		before, err := dbhelper.GetTodosTx(tx, userID, body.TodoIDs)
+		if err != nil {
+			return err
+		}
+		moved, err := dbhelper.ReorderProjectTodos(tx, userID, projectID, body.TodoIDs)
+		if err != nil || len(moved) == 0 {
+			return err
+		}
+		after, err := dbhelper.GetTodosTx(tx, userID, moved)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[string]*model.Todo, len(before))
+		for i := range before {
+			byID[before[i].ID] = &before[i]
+		}
+		for i := range after {
+			if err := recordTodoHistory(tx, auth, model.HistoryUpdated, byID[after[i].ID], &after[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if txErr != nil {
+		util.RespondError(w, http.StatusInternalServerError, txErr, "failed to reorder todos")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "reordered successfully")
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/notify"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// reminderUnits are the units of a reminder offset in minutes.
+var reminderUnits = map[byte]int{
+	'm': 1,
+	'h': 60,
+	'd': 24 * 60,
+	'w': 7 * 24 * 60,
+}
+
+// maxReminderOffset is the earliest a reminder can come before its deadline.
+const maxReminderOffset = 365 * 24 * 60
+
+// parseReminderOffset reads an offset such as "30m", "1h", "1d" or "2w" in minutes.
+func parseReminderOffset(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) < 2 {
+		return 0, fmt.Errorf("invalid reminder offset %q", raw)
+	}
+
+	unit, ok := reminderUnits[raw[len(raw)-1]]
+	n, err := strconv.Atoi(raw[:len(raw)-1])
+	if !ok || err != nil || n <= 0 || n > maxReminderOffset/unit {
+		return 0, fmt.Errorf("invalid reminder offset %q", raw)
+	}
+	return n * unit, nil
+}
+
+func GetTodoReminders(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(todoID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	userID := auth.UserID
+
+	todo, err := dbhelper.GetTodoByID(todoID, userID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch todo")
+		return
+	}
+	if todo == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	reminders, err := dbhelper.GetTodoReminders(todoID, userID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch reminders")
+		return
+	}
+	for i := range reminders {
+		reminders[i].Offset = notify.FormatOffset(reminders[i].OffsetMinutes)
+	}
+
+	util.RespondJSON(w, http.StatusOK, reminders)
+}
+
+// SetTodoReminders replaces the reminders of a todo. Kept offsets keep their
+// delivery state, so a reminder already sent is not sent again.
+func SetTodoReminders(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(todoID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	userID := auth.UserID
+
+	var body model.ReminderRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	offsets := make([]int, 0, len(body.Offsets))
+	for _, raw := range body.Offsets {
+		offset, err := parseReminderOffset(raw)
+		if err != nil {
+			util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+			return
+		}
+		offsets = append(offsets, offset)
+	}
+
+	todo, err := dbhelper.GetTodoByID(todoID, userID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch todo")
+		return
+	}
+	if todo == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		return dbhelper.SetTodoReminders(tx, todoID, offsets)
+	})
+	if txErr != nil {
+		util.RespondError(w, http.StatusInternalServerError, txErr, "failed to set reminders")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "updated successfully")
+}
+
+// GetReminderDeliveries lists the delivery attempts of the reminders of a todo.
+func GetReminderDeliveries(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(todoID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	page, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	deliveries, err := dbhelper.GetReminderDeliveries(todoID, auth.UserID, limit, (page-1)*limit)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch deliveries")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"page":  page,
+		"limit": limit,
+		"data":  deliveries,
+	})
+}
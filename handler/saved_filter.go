@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// savedFilterParams are the query parameters of GET /todos a saved filter may store.
+var savedFilterParams = map[string]bool{
+	"status":   true,
+	"days":     true,
+	"tag":      true,
+	"tag_mode": true,
+	"project":  true,
+	"q":        true,
+}
+
+// checkSavedFilter reports whether the filter and sort of body are accepted
+// by GET /todos, so that applying the saved filter later cannot fail.
+func checkSavedFilter(body model.SavedFilterRequest) error {
+	query, err := url.ParseQuery(body.Filter)
+	if err != nil {
+		return errors.New("invalid filter")
+	}
+	for key := range query {
+		if !savedFilterParams[key] {
+			return fmt.Errorf("filter cannot contain %s", key)
+		}
+	}
+
+	if _, err := parseTodoFilter(query); err != nil {
+		return err
+	}
+	if _, err := parseTodoQuery(query.Get("q")); err != nil {
+		return errors.New("invalid q: " + err.Error())
+	}
+	if _, err := parseTodoSort(body.Sort); err != nil {
+		return err
+	}
+	return nil
+}
+
+// savedFilterQuery builds the query of GET /todos for a saved filter: its filter
+// replaces the one of query, while the paging parameters of query are kept and
+// its sort and limit, when given, win over the saved ones.
+func savedFilterQuery(filter model.SavedFilter, query url.Values) (url.Values, error) {
+	values, err := url.ParseQuery(filter.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range []string{"page", "cursor", "total", "sort", "limit"} {
+		if value, ok := query[key]; ok {
+			values[key] = value
+		}
+	}
+	if values.Get("sort") == "" && filter.Sort != "" {
+		values.Set("sort", filter.Sort)
+	}
+	if values.Get("limit") == "" {
+		values.Set("limit", strconv.Itoa(filter.PageSize))
+	}
+	return values, nil
+}
+
+// parseSavedFilterRequest reads and checks the body of a saved filter request,
+// responding with an error when it is invalid.
+func parseSavedFilterRequest(w http.ResponseWriter, r *http.Request, userID, filterID string) (model.SavedFilterRequest, bool) {
+	var body model.SavedFilterRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return body, false
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return body, false
+	}
+
+	if err := checkSavedFilter(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return body, false
+	}
+	if body.PageSize == 0 {
+		body.PageSize = defaultPageLimit
+	}
+
+	taken, err := dbhelper.IsSavedFilterNameTaken(userID, filterID, body.Name)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "database error")
+		return body, false
+	}
+	if taken {
+		util.RespondError(w, http.StatusConflict, nil, "saved filter already exists")
+		return body, false
+	}
+
+	return body, true
+}
+
+func CreateSavedFilter(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	body, ok := parseSavedFilterRequest(w, r, auth.UserID, "")
+	if !ok {
+		return
+	}
+
+	filterID, err := dbhelper.CreateSavedFilter(auth.UserID, body)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to create saved filter")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusCreated, map[string]string{
+		"id": filterID,
+	})
+}
+
+func GetSavedFilters(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	filters, err := dbhelper.GetSavedFilters(auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch saved filters")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, filters)
+}
+
+func GetSavedFilterByID(w http.ResponseWriter, r *http.Request) {
+	filterID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(filterID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "saved filter not found")
+		return
+	}
+
+	filter, err := dbhelper.GetSavedFilterByID(auth.UserID, filterID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch saved filter")
+		return
+	}
+	if filter == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "saved filter not found")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, filter)
+}
+
+func UpdateSavedFilter(w http.ResponseWriter, r *http.Request) {
+	filterID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(filterID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "saved filter not found")
+		return
+	}
+
+	userID := auth.UserID
+
+	filter, err := dbhelper.GetSavedFilterByID(userID, filterID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch saved filter")
+		return
+	}
+	if filter == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "saved filter not found")
+		return
+	}
+
+	body, ok := parseSavedFilterRequest(w, r, userID, filterID)
+	if !ok {
+		return
+	}
+
+	if err := dbhelper.UpdateSavedFilter(userID, filterID, body); err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to update saved filter")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "updated successfully")
+}
+
+func DeleteSavedFilter(w http.ResponseWriter, r *http.Request) {
+	filterID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(filterID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "saved filter not found")
+		return
+	}
+
+	filter, err := dbhelper.GetSavedFilterByID(auth.UserID, filterID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch saved filter")
+		return
+	}
+	if filter == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "saved filter not found")
+		return
+	}
+
+	if err := dbhelper.DeleteSavedFilter(auth.UserID, filterID); err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to delete saved filter")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "deleted successfully")
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+)
+
+func GetUserSettings(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	settings, err := dbhelper.GetUserSettings(auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch settings")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, settings)
+}
+
+func UpdateUserSettings(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	var body model.UserSettingsPatch
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	if err := dbhelper.UpdateUserSettings(auth.UserID, body); err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to update settings")
+		return
+	}
+
+	settings, err := dbhelper.GetUserSettings(auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch settings")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, settings)
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/stream"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/Shubhouy1/todo-app/websocket"
+)
+
+const (
+	// socketPingInterval keeps idle connections alive; a client missing the
+	// pings of socketReadTimeout is disconnected.
+	socketPingInterval = 25 * time.Second
+	socketReadTimeout  = 60 * time.Second
+)
+
+// ServeWebSocket upgrades GET /ws to a WebSocket over which the client sends
+// commands and receives their replies together with the todo events of the
+// user, the same ones GET /todos/stream sends. Every message is a JSON object.
+//
+// A command carries an id chosen by the client, which is repeated in its reply:
+//
+//	{"id": "1", "type": "create", "data": {<todo as for POST /todos>}}
+//	{"id": "2", "type": "update", "todo_id": "...", "version": 3, "data": {<todo as for PUT /todos/{id}>}}
+//	{"id": "3", "type": "status", "todo_id": "...", "data": {"status": "Completed"}}
+//	{"id": "4", "type": "ping"}
+//
+// version is optional and works like an If-Match header. A command succeeds
+// with {"id": "1", "type": "ack", "data": <todo after the change>} and fails
+// with {"id": "1", "type": "error", "error": {"status": 404, "message": "todo not found"}},
+// where status is the one the same request would get over HTTP. Commands are
+// handled one at a time, in order.
+//
+// Events come as {"type": "event", "data": {"id": 42, "cursor": "7301-42", "event": "todo.updated", ...}}.
+// A client reconnecting with ?last_event_id= set to the cursor of the last event
+// it got first receives the events it missed. The connection is closed with
+// 1008 once the session is logged out or its token expires.
+func ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	// subscribe before reading the cursor, so no event is missed in between
+	updates, unsubscribe := stream.Subscribe(userID)
+	defer unsubscribe()
+
+	cursor, status, message, err := todoEventCursor(r.URL.Query().Get("last_event_id"), "last_event_id")
+	if status != 0 {
+		util.RespondError(w, status, err, message)
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		var handshakeErr websocket.HandshakeError
+		if errors.As(err, &handshakeErr) {
+			util.RespondError(w, http.StatusBadRequest, err, "invalid websocket handshake")
+			return
+		}
+		log.Printf("websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+	conn.ReadTimeout = socketReadTimeout
+
+	// the connection outlives the request context once it is hijacked
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		defer cancel()
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			// the session may have been logged out since the connection was opened
+			if !sessionValid(auth) {
+				conn.CloseWithCode(websocket.ClosePolicyViolation, "invalid session")
+				return
+			}
+
+			if err := writeSocketMessage(conn, handleSocketRequest(auth, message)); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(socketPingInterval)
+	defer ping.Stop()
+
+	for {
+		events, pending, err := dbhelper.GetTodoEventsAfter(userID, cursor, streamBatchSize)
+		if err != nil {
+			log.Printf("websocket of %s: %v", userID, err)
+			conn.CloseWithCode(websocket.CloseInternalError, "failed to fetch todo events")
+			return
+		}
+
+		for _, event := range events {
+			if err := writeSocketMessage(conn, model.SocketMessage{Type: model.SocketEvent, Data: event}); err != nil {
+				return
+			}
+			cursor = model.TodoEventCursor{XID: event.XID, ID: event.ID}
+		}
+		if len(events) == streamBatchSize {
+			continue
+		}
+
+		var retry <-chan time.Time
+		if pending {
+			retry = time.After(streamRetry)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-updates:
+		case <-retry:
+		case <-ping.C:
+			// idle connections get no commands to check the session on
+			if !sessionValid(auth) {
+				conn.CloseWithCode(websocket.ClosePolicyViolation, "invalid session")
+				return
+			}
+			if err := conn.Ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeSocketMessage(conn *websocket.Conn, message model.SocketMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return conn.WriteText(data)
+}
+
+func socketError(requestID string, status int, message string, err error) model.SocketMessage {
+	failure := &model.SocketFailure{Status: status, Message: message}
+	if err != nil {
+		failure.Detail = err.Error()
+	}
+	return model.SocketMessage{ID: requestID, Type: model.SocketError, Error: failure}
+}
+
+// handleSocketRequest runs one command sent over GET /ws and returns its reply.
+// Commands go through the same paths, history and events included, as their
+// HTTP counterparts.
+func handleSocketRequest(auth middleware.AuthContext, raw []byte) model.SocketMessage {
+	userID := auth.UserID
+
+	var request model.SocketRequest
+	if err := json.Unmarshal(raw, &request); err != nil {
+		return socketError("", http.StatusBadRequest, "invalid message", err)
+	}
+	if err := validate.Struct(request); err != nil {
+		return socketError(request.ID, http.StatusBadRequest, "failed to validate request", err)
+	}
+
+	ifMatch := ""
+	if request.Version != nil {
+		ifMatch = util.ETag(*request.Version)
+	}
+
+	todoID := request.TodoID
+	var err error
+
+	switch request.Type {
+	case model.SocketPing:
+		return model.SocketMessage{ID: request.ID, Type: model.SocketAck}
+
+	case model.SocketCreate, model.SocketUpdate:
+		var todo model.Todo
+		if err := json.Unmarshal(request.Data, &todo); err != nil {
+			return socketError(request.ID, http.StatusBadRequest, "invalid data", err)
+		}
+		if err := validate.Struct(todo); err != nil {
+			return socketError(request.ID, http.StatusBadRequest, "failed to validate request body", err)
+		}
+		if todo.Recurrence != nil && todo.Deadline == nil {
+			return socketError(request.ID, http.StatusBadRequest, "recurring todo needs a deadline", nil)
+		}
+		if status, message, err := lookupProject(userID, todo.ProjectID); status != 0 {
+			return socketError(request.ID, status, message, err)
+		}
+
+		if request.Type == model.SocketCreate {
+			todoID, err = createTodo(auth, todo)
+		} else {
+			err = updateTodo(auth, todoID, todo, ifMatch)
+		}
+
+	case model.SocketStatus:
+		var data model.SocketStatusData
+		if err := json.Unmarshal(request.Data, &data); err != nil {
+			return socketError(request.ID, http.StatusBadRequest, "invalid data", err)
+		}
+		if err := validate.Struct(data); err != nil {
+			return socketError(request.ID, http.StatusBadRequest, "failed to validate request body", err)
+		}
+		err = changeTodoStatus(auth, todoID, data.Status, ifMatch)
+	}
+
+	switch {
+	case errors.Is(err, errTodoNotFound):
+		return socketError(request.ID, http.StatusNotFound, "todo not found", nil)
+	case errors.Is(err, errOverdueStatus):
+		return socketError(request.ID, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, errCompletedAfterDeadline):
+		return socketError(request.ID, http.StatusForbidden, "cannot mark completed after deadline", nil)
+	case errors.Is(err, errPreconditionFailed):
+		return socketError(request.ID, http.StatusPreconditionFailed, "todo has been modified", nil)
+	case err != nil && request.Type == model.SocketCreate:
+		return socketError(request.ID, http.StatusInternalServerError, "failed to create todo", err)
+	case err != nil:
+		return socketError(request.ID, http.StatusInternalServerError, "failed to update todo", err)
+	}
+
+	todo, err := dbhelper.GetTodoByID(todoID, userID)
+	if err != nil {
+		return socketError(request.ID, http.StatusInternalServerError, "failed to fetch todo", err)
+	}
+	return model.SocketMessage{ID: request.ID, Type: model.SocketAck, Data: todo}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/stream"
+	"github.com/Shubhouy1/todo-app/util"
+)
+
+const (
+	// streamBatchSize bounds the events read at once when a stream catches up.
+	streamBatchSize = 100
+	// streamHeartbeat keeps idle streams from being closed by proxies.
+	streamHeartbeat = 25 * time.Second
+	// streamRetry is how soon events held back behind a running transaction
+	// are looked for again.
+	streamRetry = time.Second
+)
+
+// StreamTodos streams the todo events of the caller as Server-Sent Events. A
+// new stream starts with the events still to come, while a reconnecting client
+// sending Last-Event-ID first receives what it missed. The stream ends once the
+// session is logged out or its token expires.
+func StreamTodos(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		util.RespondError(w, http.StatusInternalServerError, nil, "streaming is not supported")
+		return
+	}
+
+	// subscribe before reading the cursor, so no event is missed in between
+	updates, unsubscribe := stream.Subscribe(userID)
+	defer unsubscribe()
+
+	cursor, status, message, err := todoEventCursor(r.Header.Get("Last-Event-ID"), "Last-Event-ID")
+	if status != 0 {
+		util.RespondError(w, status, err, message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		events, pending, err := dbhelper.GetTodoEventsAfter(userID, cursor, streamBatchSize)
+		if err != nil {
+			log.Printf("todo stream of %s: %v", userID, err)
+			return
+		}
+
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("todo stream of %s: %v", userID, err)
+				return
+			}
+			cursor = model.TodoEventCursor{XID: event.XID, ID: event.ID}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Cursor, event.Event, data)
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+		if len(events) == streamBatchSize {
+			continue
+		}
+
+		var retry <-chan time.Time
+		if pending {
+			retry = time.After(streamRetry)
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-updates:
+		case <-retry:
+		case <-heartbeat.C:
+			if !sessionValid(auth) {
+				return
+			}
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// todoEventCursor reads the cursor a client resumes a stream from, given in
+// the parameter called name, starting a new stream when there is none. Like
+// lookupProject it returns status 0 when the cursor is fine.
+func todoEventCursor(raw, name string) (model.TodoEventCursor, int, string, error) {
+	if raw != "" {
+		cursor, err := model.ParseTodoEventCursor(raw)
+		if err != nil {
+			return model.TodoEventCursor{}, http.StatusBadRequest, "invalid " + name, err
+		}
+		return cursor, 0, "", nil
+	}
+
+	cursor, err := dbhelper.GetTodoEventCursor()
+	if err != nil {
+		return model.TodoEventCursor{}, http.StatusInternalServerError, "failed to fetch todo events", err
+	}
+	return cursor, 0, "", nil
+}
+
+// sessionValid reports whether the session a long-lived connection was opened
+// with is still logged in and its token has not expired.
+func sessionValid(auth middleware.AuthContext) bool {
+	if !time.Now().Before(auth.ExpiresAt) {
+		return false
+	}
+	userID, err := dbhelper.GetUserIDBySession(auth.SessionID)
+	return err == nil && userID == auth.UserID
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// normalizeTags trims tag names and drops empty and case-insensitive duplicates.
+// Names longer than TagRequest allows are rejected by the validation of the
+// request they come with.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func GetTags(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	tags, err := dbhelper.GetTags(auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch tags")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, tags)
+}
+
+func RenameTag(w http.ResponseWriter, r *http.Request) {
+	tagID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	if validate.Var(tagID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "tag not found")
+		return
+	}
+
+	var body model.TagRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	tag, err := dbhelper.GetTagByID(userID, tagID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch tag")
+		return
+	}
+	if tag == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "tag not found")
+		return
+	}
+
+	taken, err := dbhelper.IsTagNameTaken(userID, tagID, body.Name)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "database error")
+		return
+	}
+	if taken {
+		util.RespondError(w, http.StatusConflict, nil, "tag already exists, merge it instead")
+		return
+	}
+
+	if err := dbhelper.RenameTag(userID, tagID, body.Name); err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to rename tag")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "renamed successfully")
+}
+
+func MergeTag(w http.ResponseWriter, r *http.Request) {
+	tagID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	if validate.Var(tagID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "tag not found")
+		return
+	}
+
+	var body model.MergeTagRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	if body.TargetID == tagID {
+		util.RespondError(w, http.StatusBadRequest, nil, "cannot merge a tag into itself")
+		return
+	}
+
+	for _, id := range []string{tagID, body.TargetID} {
+		tag, err := dbhelper.GetTagByID(userID, id)
+		if err != nil {
+			util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch tag")
+			return
+		}
+		if tag == nil {
+			util.RespondError(w, http.StatusNotFound, nil, "tag not found")
+			return
+		}
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		return dbhelper.MergeTags(tx, userID, tagID, body.TargetID)
+	})
+	if txErr != nil {
+		util.RespondError(w, http.StatusInternalServerError, txErr, "failed to merge tags")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "merged successfully")
+}
+
+func DeleteTag(w http.ResponseWriter, r *http.Request) {
+	tagID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	if validate.Var(tagID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "tag not found")
+		return
+	}
+
+	tag, err := dbhelper.GetTagByID(userID, tagID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch tag")
+		return
+	}
+	if tag == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "tag not found")
+		return
+	}
+
+	if err := dbhelper.DeleteTag(userID, tagID); err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to delete tag")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "deleted successfully")
+}
@@ -1,15 +1,25 @@
 package handler
 
 import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Shubhouy1/todo-app/database"
 	"github.com/Shubhouy1/todo-app/database/dbhelper"
 	"github.com/Shubhouy1/todo-app/middleware"
 	"github.com/Shubhouy1/todo-app/model"
 	"github.com/Shubhouy1/todo-app/util"
 	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
 )
 
 func CreateTodo(w http.ResponseWriter, r *http.Request) {
@@ -32,8 +42,21 @@ func CreateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := dbhelper.CreateTodo(userID, todo.Title, todo.Status, todo.Description, todo.Deadline)
-	if err != nil {
+	if todo.Recurrence != nil && todo.Deadline == nil {
+		util.RespondError(w, http.StatusBadRequest, nil, "recurring todo needs a deadline")
+		return
+	}
+
+	if !checkProject(w, userID, todo.ProjectID) {
+		return
+	}
+
+	_, err := createTodo(auth, todo)
+	switch {
+	case errors.Is(err, errOverdueStatus):
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	case err != nil:
 		util.RespondError(w, http.StatusInternalServerError, err, "failed to create todo")
 		return
 	}
@@ -63,19 +86,35 @@ func UpdateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := dbhelper.UpdateTodoData(
-		userID,
-		todoID,
-		todo.Title,
-		todo.Status,
-		todo.Description,
-		todo.Deadline,
-	)
-	if err != nil {
+	if todo.Recurrence != nil && todo.Deadline == nil {
+		util.RespondError(w, http.StatusBadRequest, nil, "recurring todo needs a deadline")
+		return
+	}
+
+	if !checkProject(w, userID, todo.ProjectID) {
+		return
+	}
+
+	err := updateTodo(auth, todoID, todo, r.Header.Get("If-Match"))
+	switch {
+	case errors.Is(err, errTodoNotFound):
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	case errors.Is(err, errOverdueStatus):
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	case errors.Is(err, errCompletedAfterDeadline):
+		util.RespondError(w, http.StatusForbidden, nil, "cannot mark completed after deadline")
+		return
+	case errors.Is(err, errPreconditionFailed):
+		util.RespondError(w, http.StatusPreconditionFailed, nil, "todo has been modified")
+		return
+	case err != nil:
 		util.RespondError(w, http.StatusInternalServerError, err, "failed to update todo")
 		return
 	}
 
+	setTodoETag(w, userID, todoID)
 	util.RespondJSON(w, http.StatusOK, "updated successfully")
 }
 
@@ -89,15 +128,85 @@ func DeleteTodo(w http.ResponseWriter, r *http.Request) {
 
 	userID := auth.UserID
 
-	err := dbhelper.DeleteTodo(userID, todoID)
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		return withTodoHistory(tx, auth, todoID, model.HistoryDeleted, func() error {
+			deleted, err := dbhelper.DeleteTodo(tx, userID, todoID)
+			if err == nil && !deleted {
+				return errTodoNotFound
+			}
+			return err
+		})
+	})
+	if errors.Is(err, errTodoNotFound) {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
 	if err != nil {
-		util.RespondError(w, http.StatusNotFound, err, "todo not found")
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to delete todo")
 		return
 	}
 
 	util.RespondJSON(w, http.StatusOK, "deleted successfully")
 }
 
+// GetTrash lists the todos deleted by the user that have not been purged yet.
+func GetTrash(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	page, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	todos, err := dbhelper.GetArchivedTodos(auth.UserID, limit, (page-1)*limit)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch trash")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"page":  page,
+		"limit": limit,
+		"data":  todos,
+	})
+}
+
+func RestoreTodo(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	var restored bool
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		return withTodoHistory(tx, auth, todoID, model.HistoryRestored, func() error {
+			var err error
+			restored, err = dbhelper.RestoreTodo(tx, auth.UserID, todoID)
+			return err
+		})
+	})
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to restore todo")
+		return
+	}
+	if !restored {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found in trash")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "restored successfully")
+}
+
+// UpdateTodoStatus applies a JSON merge patch (RFC 7396) to a todo, so any subset
+// of its fields can change and null clears the optional ones. A status change
+// still follows the rules of applyTodoStatus, so {"status": "Completed"} works as before.
 func UpdateTodoStatus(w http.ResponseWriter, r *http.Request) {
 	todoID := chi.URLParam(r, "id")
 	auth, ok := middleware.GetAuthContext(r)
@@ -107,38 +216,348 @@ func UpdateTodoStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := auth.UserID
-	var body struct {
-		Status string `json:"status" validate:"required,oneof=Completed 'Not Completed' Pending"`
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && mediaType != "application/merge-patch+json") {
+			util.RespondError(w, http.StatusUnsupportedMediaType, nil, "expected application/merge-patch+json")
+			return
+		}
 	}
 
+	var body map[string]interface{}
 	if err := util.ParseBody(r, &body); err != nil {
 		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
 		return
 	}
 
-	if err := validate.Struct(body); err != nil {
-		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+	fields, err := todoPatchFieldNames(body)
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
 		return
 	}
 
-	deadline, err := dbhelper.GetDeadline(todoID, userID)
-	if err != nil {
-		util.RespondError(w, http.StatusNotFound, err, "todo not found")
+	if projectID, ok := body["project_id"].(string); ok && !checkProject(w, userID, &projectID) {
 		return
 	}
 
-	if time.Now().After(deadline) && body.Status == "Completed" {
+	err = database.Tx(func(tx *sqlx.Tx) error {
+		if err := checkTodoVersion(tx, userID, todoID, r.Header.Get("If-Match")); err != nil {
+			return err
+		}
+
+		todo, err := dbhelper.GetTodoTx(tx, todoID, userID)
+		if err != nil {
+			return err
+		}
+
+		patch, err := applyTodoPatch(*todo, body)
+		if err != nil {
+			return err
+		}
+		if patch.Status == "Overdue" && todo.Status != "Overdue" {
+			return errOverdueStatus
+		}
+		if err := validate.StructPartial(patch, fields...); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidPatch, err)
+		}
+		// StructPartial does not descend into nested structs
+		if _, ok := body["recurrence"]; ok && patch.Recurrence != nil {
+			if err := validate.Struct(patch.Recurrence); err != nil {
+				return fmt.Errorf("%w: %v", errInvalidPatch, err)
+			}
+		}
+		if patch.Recurrence != nil && patch.Deadline == nil {
+			return fmt.Errorf("%w: recurring todo needs a deadline", errInvalidPatch)
+		}
+
+		updated := *todo
+		updated.Title = patch.Title
+		updated.Description = patch.Description
+		updated.Deadline = patch.Deadline
+		updated.Priority = patch.Priority
+		updated.ProjectID = patch.ProjectID
+		updated.AutoComplete = patch.AutoComplete
+		updated.Recurrence = patch.Recurrence
+
+		err = withTodoHistory(tx, auth, todoID, model.HistoryUpdated, func() error {
+			if err := dbhelper.UpdateTodoData(tx, userID, todoID, updated); err != nil {
+				return err
+			}
+			if _, ok := body["tags"]; !ok {
+				return nil
+			}
+			return dbhelper.SetTodoTags(tx, userID, todoID, normalizeTags(patch.Tags))
+		})
+		if err != nil || patch.Status == todo.Status {
+			return err
+		}
+
+		return applyTodoStatus(tx, auth, updated, patch.Status)
+	})
+	switch {
+	case errors.Is(err, errTodoNotFound):
+		util.RespondError(w, http.StatusNotFound, err, "todo not found")
+		return
+	case errors.Is(err, errInvalidPatch):
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	case errors.Is(err, errOverdueStatus):
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	case errors.Is(err, errCompletedAfterDeadline):
 		util.RespondError(w, http.StatusForbidden, nil, "cannot mark completed after deadline")
 		return
+	case errors.Is(err, errPreconditionFailed):
+		util.RespondError(w, http.StatusPreconditionFailed, nil, "todo has been modified")
+		return
+	case err != nil:
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to update todo")
+		return
 	}
 
-	err = dbhelper.UpdateStatus(todoID, userID, body.Status)
+	setTodoETag(w, userID, todoID)
+	util.RespondJSON(w, http.StatusOK, "updated successfully")
+}
+
+// todoPatchFields maps the JSON fields accepted by PATCH /todos/{id} to their
+// model.TodoPatch field, telling whether null may clear them.
+var todoPatchFields = map[string]struct {
+	field    string
+	nullable bool
+}{
+	"title":         {"Title", false},
+	"description":   {"Description", true},
+	"deadline":      {"Deadline", true},
+	"status":        {"Status", false},
+	"priority":      {"Priority", false},
+	"project_id":    {"ProjectID", true},
+	"tags":          {"Tags", true},
+	"auto_complete": {"AutoComplete", false},
+	"recurrence":    {"Recurrence", true},
+}
+
+// todoPatchFieldNames checks the keys of a merge patch and returns the
+// model.TodoPatch fields it sets, which are the only ones validated.
+func todoPatchFieldNames(body map[string]interface{}) ([]string, error) {
+	fields := make([]string, 0, len(body))
+	for key, value := range body {
+		patchField, ok := todoPatchFields[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", key)
+		}
+		if value == nil {
+			if !patchField.nullable {
+				return nil, fmt.Errorf("field %q cannot be null", key)
+			}
+			continue
+		}
+		fields = append(fields, patchField.field)
+	}
+	return fields, nil
+}
+
+// applyTodoPatch merges body onto the patchable fields of todo.
+func applyTodoPatch(todo model.Todo, body map[string]interface{}) (model.TodoPatch, error) {
+	current := model.TodoPatch{
+		Title:        todo.Title,
+		Description:  todo.Description,
+		Deadline:     todo.Deadline,
+		Status:       todo.Status,
+		Priority:     todo.Priority,
+		ProjectID:    todo.ProjectID,
+		Tags:         todo.Tags,
+		AutoComplete: todo.AutoComplete,
+		Recurrence:   todo.Recurrence,
+	}
+
+	var document interface{}
+	data, err := json.Marshal(current)
 	if err != nil {
-		util.RespondError(w, http.StatusInternalServerError, err, "failed to update status")
+		return model.TodoPatch{}, err
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return model.TodoPatch{}, err
+	}
+
+	data, err = json.Marshal(util.MergePatch(document, body))
+	if err != nil {
+		return model.TodoPatch{}, err
+	}
+
+	var patched model.TodoPatch
+	if err := json.Unmarshal(data, &patched); err != nil {
+		return model.TodoPatch{}, fmt.Errorf("%w: %v", errInvalidPatch, err)
+	}
+	return patched, nil
+}
+
+var (
+	errTodoNotFound           = errors.New("todo not found")
+	errCompletedAfterDeadline = errors.New("cannot mark completed after deadline")
+	errPreconditionFailed     = errors.New("todo has been modified")
+	errInvalidPatch           = errors.New("invalid patch")
+	// errOverdueStatus rejects setting the Overdue status, which only the server
+	// does; sending it back unchanged is fine.
+	errOverdueStatus = errors.New("status Overdue is set by the server")
+)
+
+// checkTodoVersion locks the todo for the rest of tx and, when the client sent
+// an If-Match header, makes sure the todo is still at the version the client has seen.
+func checkTodoVersion(tx *sqlx.Tx, userID, todoID, ifMatch string) error {
+	version, err := dbhelper.LockTodoVersion(tx, todoID, userID)
+	if err == sql.ErrNoRows {
+		return errTodoNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if ifMatch != "" && !util.MatchETag(ifMatch, util.ETag(version)) {
+		return errPreconditionFailed
+	}
+	return nil
+}
+
+// setTodoETag sends the version the todo has after a change as its ETag.
+func setTodoETag(w http.ResponseWriter, userID, todoID string) {
+	todo, err := dbhelper.GetTodoByID(todoID, userID)
+	if err != nil || todo == nil {
 		return
 	}
+	w.Header().Set("ETag", util.ETag(todo.Version))
+}
 
-	util.RespondJSON(w, http.StatusOK, "updated successfully")
+// createTodo creates a validated todo with its tags and returns its id.
+func createTodo(auth middleware.AuthContext, todo model.Todo) (string, error) {
+	userID := auth.UserID
+	tags := normalizeTags(todo.Tags)
+	if todo.Status == "Overdue" {
+		return "", errOverdueStatus
+	}
+
+	var todoID string
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var err error
+		todoID, err = dbhelper.CreateTodo(tx, userID, todo)
+		if err != nil {
+			return err
+		}
+		if err := dbhelper.SetTodoTags(tx, userID, todoID, tags); err != nil {
+			return err
+		}
+
+		created, err := dbhelper.GetTodoTx(tx, todoID, userID)
+		if err != nil {
+			return err
+		}
+		return recordTodoHistory(tx, auth, model.HistoryCreated, nil, created)
+	})
+	return todoID, err
+}
+
+// updateTodo replaces the data of a todo with a validated todo, in a transaction
+// of its own. ifMatch is the If-Match header of the request, if any. A changed
+// status goes through applyTodoStatus, as with PATCH.
+func updateTodo(auth middleware.AuthContext, todoID string, todo model.Todo, ifMatch string) error {
+	userID := auth.UserID
+
+	return database.Tx(func(tx *sqlx.Tx) error {
+		if err := checkTodoVersion(tx, userID, todoID, ifMatch); err != nil {
+			return err
+		}
+
+		current, err := dbhelper.GetTodoTx(tx, todoID, userID)
+		if err != nil {
+			return err
+		}
+		if todo.Status == "Overdue" && current.Status != "Overdue" {
+			return errOverdueStatus
+		}
+
+		err = withTodoHistory(tx, auth, todoID, model.HistoryUpdated, func() error {
+			if err := dbhelper.UpdateTodoData(tx, userID, todoID, todo); err != nil {
+				return err
+			}
+			// tags are left untouched when the field is omitted
+			if todo.Tags == nil {
+				return nil
+			}
+			return dbhelper.SetTodoTags(tx, userID, todoID, normalizeTags(todo.Tags))
+		})
+		if err != nil || todo.Status == "" || todo.Status == current.Status {
+			return err
+		}
+
+		updated, err := dbhelper.GetTodoTx(tx, todoID, userID)
+		if err != nil {
+			return err
+		}
+		return applyTodoStatus(tx, auth, *updated, todo.Status)
+	})
+}
+
+// changeTodoStatus changes only the status of a todo, in a transaction of its own.
+// ifMatch is the If-Match header of the request, if any.
+func changeTodoStatus(auth middleware.AuthContext, todoID, status, ifMatch string) error {
+	return database.Tx(func(tx *sqlx.Tx) error {
+		if err := checkTodoVersion(tx, auth.UserID, todoID, ifMatch); err != nil {
+			return err
+		}
+
+		todo, err := dbhelper.GetTodoTx(tx, todoID, auth.UserID)
+		if err != nil {
+			return err
+		}
+		return applyTodoStatus(tx, auth, *todo, status)
+	})
+}
+
+// applyTodoStatus is the path every status change of a todo goes through,
+// whether it comes from PATCH /todos/{id} or from a completed checklist.
+// Completing a todo after its deadline follows the late completion policy of the user.
+// Completing an occurrence of a recurring todo creates the next occurrence.
+func applyTodoStatus(tx *sqlx.Tx, auth middleware.AuthContext, todo model.Todo, status string) error {
+	userID := auth.UserID
+
+	late := false
+	if status == "Completed" && todo.Deadline != nil && time.Now().After(*todo.Deadline) {
+		policy, err := dbhelper.GetLateCompletion(tx, userID)
+		if err != nil {
+			return err
+		}
+		switch policy {
+		case model.LateCompletionForbid:
+			return errCompletedAfterDeadline
+		case model.LateCompletionFlag:
+			late = true
+		}
+	}
+
+	err := withTodoHistory(tx, auth, todo.ID, model.HistoryStatusChanged, func() error {
+		return dbhelper.UpdateStatus(tx, todo.ID, userID, status, late)
+	})
+	if err != nil {
+		return err
+	}
+	if status != "Completed" || todo.Recurrence == nil || todo.Deadline == nil {
+		return nil
+	}
+
+	next, ok := util.NextOccurrence(*todo.Recurrence, *todo.Deadline)
+	if !ok {
+		return nil
+	}
+	nextID, err := dbhelper.CreateNextOccurrence(tx, userID, todo.ID, next)
+	if err != nil || nextID == "" {
+		return err
+	}
+
+	created, err := dbhelper.GetTodoTx(tx, nextID, userID)
+	if err != nil {
+		return err
+	}
+	return recordTodoHistory(tx, auth, model.HistoryCreated, nil, created)
 }
 
 func GetTodoByID(w http.ResponseWriter, r *http.Request) {
@@ -163,33 +582,60 @@ func GetTodoByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the version covers the checklist too, so a match can skip loading it
+	etag := util.ETag(todo.Version)
+	w.Header().Set("ETag", etag)
+	if inm := r.Header.Get("If-None-Match"); inm != "" && util.MatchETag(inm, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	todo.Items, err = dbhelper.GetTodoItems(todoID, userID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch checklist")
+		return
+	}
+
 	util.RespondJSON(w, http.StatusOK, todo)
 }
 
-// GetTodos remove id filter and make separate api for GetTodobyID
-func GetTodos(w http.ResponseWriter, r *http.Request) {
+// GetTodoSeries lists every occurrence of a recurring todo, completed ones included.
+func GetTodoSeries(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
 	auth, ok := middleware.GetAuthContext(r)
 	if !ok {
 		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
 		return
 	}
 
-	userID := auth.UserID
+	todos, err := dbhelper.GetTodoSeries(todoID, auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch series")
+		return
+	}
+	if len(todos) == 0 {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, todos)
+}
 
-	status := r.URL.Query().Get("status")
-	daysStr := r.URL.Query().Get("days")
+// defaultPageLimit is the page size of the listing endpoints without ?limit=.
+const defaultPageLimit = 10
 
-	pageStr := r.URL.Query().Get("page")
-	limitStr := r.URL.Query().Get("limit")
+// parsePage reads the page and limit query parameters.
+func parsePage(query url.Values) (page, limit int, err error) {
+	pageStr := query.Get("page")
+	limitStr := query.Get("limit")
 
-	page := 1
-	limit := 10
+	page = 1
+	limit = defaultPageLimit
 
 	if pageStr != "" {
 		p, err := strconv.Atoi(pageStr)
 		if err != nil || p <= 0 {
-			util.RespondError(w, http.StatusBadRequest, nil, "invalid page")
-			return
+			return 0, 0, errors.New("invalid page")
 		}
 		page = p
 	}
@@ -197,30 +643,145 @@ func GetTodos(w http.ResponseWriter, r *http.Request) {
 	if limitStr != "" {
 		l, err := strconv.Atoi(limitStr)
 		if err != nil || l <= 0 || l > 100 {
-			util.RespondError(w, http.StatusBadRequest, nil, "invalid limit")
-			return
+			return 0, 0, errors.New("invalid limit")
 		}
 		limit = l
 	}
 
-	offset := (page - 1) * limit
+	return page, limit, nil
+}
+
+// parseTodoFilter reads the status, days, tag, tag_mode and project query
+// parameters shared by the endpoints listing todos.
+func parseTodoFilter(query url.Values) (model.TodoFilter, error) {
+	status := query.Get("status")
+	daysStr := query.Get("days")
+	tagMode := query.Get("tag_mode")
+	projectStr := query.Get("project")
+
+	if tagMode == "" {
+		tagMode = model.TagModeAny
+	}
+	if tagMode != model.TagModeAny && tagMode != model.TagModeAll {
+		return model.TodoFilter{}, errors.New("invalid tag_mode")
+	}
+
+	var projectID *string
+	if projectStr != "" {
+		if err := validate.Var(projectStr, "uuid"); err != nil {
+			return model.TodoFilter{}, errors.New("invalid project")
+		}
+		projectID = &projectStr
+	}
 
 	var selectedDate *time.Time
 	if daysStr != "" {
 		d, err := strconv.Atoi(daysStr)
 		if err != nil || d <= 0 {
-			util.RespondError(w, http.StatusBadRequest, nil, "invalid days")
-			return
+			return model.TodoFilter{}, errors.New("invalid days")
 		}
 
 		t := time.Now().AddDate(0, 0, d)
 		selectedDate = &t
 	}
 
+	return model.TodoFilter{
+		Status:    status,
+		Deadline:  selectedDate,
+		Tags:      normalizeTags(query["tag"]),
+		TagMode:   tagMode,
+		ProjectID: projectID,
+	}, nil
+}
+
+// GetTodos remove id filter and make separate api for GetTodobyID
+func GetTodos(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	query := r.URL.Query()
+
+	if viewID := query.Get("view"); viewID != "" {
+		if err := validate.Var(viewID, "uuid"); err != nil {
+			util.RespondError(w, http.StatusBadRequest, err, "invalid view")
+			return
+		}
+
+		view, err := dbhelper.GetSavedFilterByID(userID, viewID)
+		if err != nil {
+			util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch saved filter")
+			return
+		}
+		if view == nil {
+			util.RespondError(w, http.StatusNotFound, nil, "saved filter not found")
+			return
+		}
+
+		query, err = savedFilterQuery(*view, query)
+		if err != nil {
+			util.RespondError(w, http.StatusInternalServerError, err, "invalid saved filter")
+			return
+		}
+	}
+
+	page, limit, err := parsePage(query)
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	offset := (page - 1) * limit
+
+	filter, err := parseTodoFilter(query)
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	filter.Query, err = parseTodoQuery(query.Get("q"))
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, "invalid q: "+err.Error())
+		return
+	}
+
+	sort, err := parseTodoSort(query.Get("sort"))
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid sort")
+		return
+	}
+
+	var cursor *model.TodoCursor
+	if raw := query.Get("cursor"); raw != "" {
+		if query.Get("page") != "" {
+			util.RespondError(w, http.StatusBadRequest, nil, "use either cursor or page")
+			return
+		}
+		cursor, err = decodeTodoCursor(raw)
+		if err != nil || !dbhelper.IsTodoCursorValid(filter, sort, *cursor) {
+			util.RespondError(w, http.StatusBadRequest, err, "invalid cursor")
+			return
+		}
+	}
+
+	withTotal := false
+	if raw := query.Get("total"); raw != "" {
+		withTotal, err = strconv.ParseBool(raw)
+		if err != nil {
+			util.RespondError(w, http.StatusBadRequest, nil, "invalid total")
+			return
+		}
+	}
+
 	todos, err := dbhelper.GetTodos(
 		userID,
-		status,
-		selectedDate,
+		filter,
+		sort,
+		cursor,
 		limit,
 		offset,
 	)
@@ -229,9 +790,115 @@ func GetTodos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response := map[string]interface{}{
+		"limit":       limit,
+		"data":        todos.Todos,
+		"next_cursor": encodeTodoCursor(todos.Next),
+		"prev_cursor": encodeTodoCursor(todos.Prev),
+	}
+	if cursor == nil {
+		response["page"] = page
+	}
+
+	if withTotal {
+		total, err := dbhelper.CountTodos(userID, filter)
+		if err != nil {
+			util.RespondError(w, http.StatusInternalServerError, err, "failed to count todos")
+			return
+		}
+		response["total"] = total
+	}
+
+	util.RespondJSON(w, http.StatusOK, response)
+}
+
+// encodeTodoCursor turns a cursor into the opaque string handed to clients,
+// or nil when there is no cursor.
+func encodeTodoCursor(cursor *model.TodoCursor) interface{} {
+	if cursor == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return nil
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTodoCursor(raw string) (*model.TodoCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor model.TodoCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// SearchTodos runs a full-text search over titles and descriptions, honouring
+// the same filters as GetTodos.
+func SearchTodos(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		util.RespondError(w, http.StatusBadRequest, nil, "q is required")
+		return
+	}
+
+	page, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	filter, err := parseTodoFilter(r.URL.Query())
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	results, err := dbhelper.SearchTodos(auth.UserID, q, filter, limit, (page-1)*limit)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to search todos")
+		return
+	}
+
 	util.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"page":  page,
 		"limit": limit,
-		"data":  todos,
+		"data":  results,
 	})
 }
+
+// parseTodoSort reads a comma separated ?sort= list such as "deadline,-priority,title",
+// where a leading "-" sorts that field in descending order.
+func parseTodoSort(raw string) ([]model.SortField, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var sort []model.SortField
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		field := model.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !dbhelper.IsTodoSortField(field.Field) {
+			return nil, fmt.Errorf("unknown sort field %q", field.Field)
+		}
+		if seen[field.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", field.Field)
+		}
+		seen[field.Field] = true
+		sort = append(sort, field)
+	}
+	return sort, nil
+}
@@ -0,0 +1,514 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// todoCSVColumns are the columns of a CSV export. An import needs title and
+// ignores the columns it cannot set, like id and created_at.
+var todoCSVColumns = []string{
+	"id", "title", "description", "status", "priority", "deadline", "tags",
+	"project_id", "auto_complete", "recurrence", "created_at", "completed_at",
+}
+
+// todoTxtPriorities maps priorities onto todo.txt's (A) to (Z). P3, the
+// default priority, is written without one.
+var todoTxtPriorities = map[string]string{
+	"P0": "A",
+	"P1": "B",
+	"P2": "C",
+}
+
+// todoWriter writes the todos of an export in one format. Nothing is written
+// before the first todo or Close, so a failing export can still respond with an error.
+type todoWriter interface {
+	Write(todo model.Todo) error
+	Flush() error
+	Close() error
+}
+
+func newTodoWriter(format string, w io.Writer) todoWriter {
+	switch format {
+	case model.FormatCSV:
+		return &csvTodoWriter{w: csv.NewWriter(w)}
+	case model.FormatTodoTxt:
+		return &todoTxtWriter{w: bufio.NewWriter(w)}
+	default:
+		return &jsonTodoWriter{w: bufio.NewWriter(w)}
+	}
+}
+
+type csvTodoWriter struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (c *csvTodoWriter) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	return c.w.Write(todoCSVColumns)
+}
+
+func (c *csvTodoWriter) Write(todo model.Todo) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+
+	projectID := ""
+	if todo.ProjectID != nil {
+		projectID = *todo.ProjectID
+	}
+	recurrence := ""
+	if todo.Recurrence != nil {
+		data, err := json.Marshal(todo.Recurrence)
+		if err != nil {
+			return err
+		}
+		recurrence = string(data)
+	}
+
+	return c.w.Write([]string{
+		todo.ID,
+		todo.Title,
+		todo.Description,
+		todo.Status,
+		todo.Priority,
+		formatExportTime(todo.Deadline),
+		strings.Join(todo.Tags, ","),
+		projectID,
+		strconv.FormatBool(todo.AutoComplete),
+		recurrence,
+		formatExportTime(&todo.CreatedAt),
+		formatExportTime(todo.CompletedAt),
+	})
+}
+
+func (c *csvTodoWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvTodoWriter) Close() error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	return c.Flush()
+}
+
+// jsonTodoWriter writes a JSON array, one todo per line.
+type jsonTodoWriter struct {
+	w       *bufio.Writer
+	started bool
+}
+
+func (j *jsonTodoWriter) Write(todo model.Todo) error {
+	data, err := json.Marshal(todo)
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if !j.started {
+		separator = "[\n"
+		j.started = true
+	}
+	if _, err := j.w.WriteString(separator); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonTodoWriter) Flush() error {
+	return j.w.Flush()
+}
+
+func (j *jsonTodoWriter) Close() error {
+	end := "\n]\n"
+	if !j.started {
+		end = "[]\n"
+	}
+	if _, err := j.w.WriteString(end); err != nil {
+		return err
+	}
+	return j.w.Flush()
+}
+
+// todoTxtWriter writes todo.txt lines such as
+//
+//	x 2026-10-02 2026-09-30 Send weekly report +work due:2026-10-03 pri:B
+//	(A) 2026-10-01 Renew passport due:2026-11-01 status:Pending rec:1m
+//
+// Tags become +tags, and deadlines keep their date only. Descriptions, projects
+// and weekly recurrences on chosen weekdays have no place in the format.
+type todoTxtWriter struct {
+	w *bufio.Writer
+}
+
+func (t *todoTxtWriter) Write(todo model.Todo) error {
+	var parts []string
+	priority := todoTxtPriorities[todo.Priority]
+
+	if todo.Status == "Completed" {
+		parts = append(parts, "x")
+		if todo.CompletedAt != nil {
+			parts = append(parts, todo.CompletedAt.UTC().Format("2006-01-02"))
+		}
+	} else if priority != "" {
+		parts = append(parts, "("+priority+")")
+	}
+	parts = append(parts, todo.CreatedAt.UTC().Format("2006-01-02"))
+	parts = append(parts, strings.Fields(todo.Title)...)
+
+	for _, tag := range todo.Tags {
+		parts = append(parts, "+"+strings.Join(strings.Fields(tag), "_"))
+	}
+	if todo.Deadline != nil {
+		parts = append(parts, "due:"+todo.Deadline.UTC().Format("2006-01-02"))
+	}
+	if todo.Status == "Completed" && priority != "" {
+		parts = append(parts, "pri:"+priority)
+	}
+	if todo.Status != "Completed" && todo.Status != "Not Completed" {
+		parts = append(parts, "status:"+strings.ReplaceAll(todo.Status, " ", "_"))
+	}
+	if rec := todoTxtRecurrence(todo.Recurrence); rec != "" {
+		parts = append(parts, "rec:"+rec)
+	}
+
+	_, err := t.w.WriteString(strings.Join(parts, " ") + "\n")
+	return err
+}
+
+func (t *todoTxtWriter) Flush() error {
+	return t.w.Flush()
+}
+
+func (t *todoTxtWriter) Close() error {
+	return t.w.Flush()
+}
+
+// todoTxtRecurrence writes the rec: value of rule, like 2w, or "" when it cannot be written.
+func todoTxtRecurrence(rule *model.Recurrence) string {
+	if rule == nil || rule.Until != nil || len(rule.Weekdays) > 0 {
+		return ""
+	}
+
+	interval := rule.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+	switch rule.Frequency {
+	case model.FrequencyDaily:
+		return strconv.Itoa(interval) + "d"
+	case model.FrequencyWeekly:
+		return strconv.Itoa(interval) + "w"
+	case model.FrequencyMonthly:
+		return strconv.Itoa(interval) + "m"
+	}
+	return ""
+}
+
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// todoReader reads the rows of an import, at most limit of them. Rows that
+// cannot be read are reported in the file, while an error means the whole file
+// is unreadable or, with model.ErrTooManyImportRows, too long.
+type todoReader func(r io.Reader, limit int) (model.ImportFile, error)
+
+var todoReaders = map[string]todoReader{
+	model.FormatCSV:     readCSVTodos,
+	model.FormatJSON:    readJSONTodos,
+	model.FormatTodoTxt: readTodoTxtTodos,
+}
+
+func readCSVTodos(r io.Reader, limit int) (model.ImportFile, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return model.ImportFile{}, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return model.ImportFile{}, errors.New("CSV header has no title column")
+	}
+
+	var file model.ImportFile
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if file.Full(limit) {
+			return model.ImportFile{}, model.ErrTooManyImportRows
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			file.Errors = append(file.Errors, model.ImportRowError{Row: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return model.ImportFile{}, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		todo, err := csvTodo(field)
+		if err != nil {
+			file.Errors = append(file.Errors, model.ImportRowError{Row: line, Error: err.Error()})
+			continue
+		}
+		file.Rows = append(file.Rows, model.ImportRow{Row: line, Todo: todo})
+	}
+	return file, nil
+}
+
+func csvTodo(field func(name string) string) (model.Todo, error) {
+	todo := model.Todo{
+		Title:       field("title"),
+		Description: field("description"),
+		Status:      field("status"),
+		Priority:    field("priority"),
+	}
+
+	var err error
+	if todo.Deadline, err = parseImportTime(field("deadline")); err != nil {
+		return todo, fmt.Errorf("invalid deadline: %w", err)
+	}
+	if todo.CompletedAt, err = parseImportTime(field("completed_at")); err != nil {
+		return todo, fmt.Errorf("invalid completed_at: %w", err)
+	}
+	if tags := field("tags"); tags != "" {
+		todo.Tags = strings.Split(tags, ",")
+	}
+	if projectID := field("project_id"); projectID != "" {
+		todo.ProjectID = &projectID
+	}
+	if autoComplete := field("auto_complete"); autoComplete != "" {
+		if todo.AutoComplete, err = strconv.ParseBool(autoComplete); err != nil {
+			return todo, errors.New("invalid auto_complete")
+		}
+	}
+	if recurrence := field("recurrence"); recurrence != "" {
+		todo.Recurrence = &model.Recurrence{}
+		if err := json.Unmarshal([]byte(recurrence), todo.Recurrence); err != nil {
+			return todo, errors.New("invalid recurrence")
+		}
+	}
+	return todo, nil
+}
+
+// readJSONTodos reads an array of todos as GET /todos/export?format=json writes
+// it, decoding one element at a time.
+func readJSONTodos(r io.Reader, limit int) (model.ImportFile, error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return model.ImportFile{}, errors.New("expected a JSON array of todos")
+	}
+
+	var file model.ImportFile
+	for row := 1; decoder.More(); row++ {
+		if file.Full(limit) {
+			return model.ImportFile{}, model.ErrTooManyImportRows
+		}
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return model.ImportFile{}, fmt.Errorf("invalid JSON: %w", err)
+		}
+
+		var todo model.Todo
+		if err := json.Unmarshal(element, &todo); err != nil {
+			file.Errors = append(file.Errors, model.ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+		file.Rows = append(file.Rows, model.ImportRow{Row: row, Todo: model.Todo{
+			Title:        todo.Title,
+			Description:  todo.Description,
+			Status:       todo.Status,
+			Priority:     todo.Priority,
+			Deadline:     todo.Deadline,
+			Tags:         todo.Tags,
+			ProjectID:    todo.ProjectID,
+			AutoComplete: todo.AutoComplete,
+			Recurrence:   todo.Recurrence,
+			CompletedAt:  todo.CompletedAt,
+		}})
+	}
+	if _, err := decoder.Token(); err != nil {
+		return model.ImportFile{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return file, nil
+}
+
+func readTodoTxtTodos(r io.Reader, limit int) (model.ImportFile, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var file model.ImportFile
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if file.Full(limit) {
+			return model.ImportFile{}, model.ErrTooManyImportRows
+		}
+
+		todo, err := todoTxtTodo(text)
+		if err != nil {
+			file.Errors = append(file.Errors, model.ImportRowError{Row: line, Error: err.Error()})
+			continue
+		}
+		file.Rows = append(file.Rows, model.ImportRow{Row: line, Todo: todo})
+	}
+	if err := scanner.Err(); err != nil {
+		return model.ImportFile{}, err
+	}
+	return file, nil
+}
+
+// todoTxtTodo reads a todo.txt line as todoTxtWriter writes it. Contexts
+// become tags like projects do, and other key:value pairs stay in the title.
+func todoTxtTodo(line string) (model.Todo, error) {
+	todo := model.Todo{Status: "Not Completed"}
+	words := strings.Fields(line)
+
+	isDate := func(i int) bool {
+		if i >= len(words) {
+			return false
+		}
+		_, err := time.Parse("2006-01-02", words[i])
+		return err == nil
+	}
+
+	if words[0] == "x" {
+		todo.Status = "Completed"
+		words = words[1:]
+		if isDate(0) {
+			completedAt, _ := time.Parse("2006-01-02", words[0])
+			todo.CompletedAt = &completedAt
+			words = words[1:]
+		}
+	} else if len(words[0]) == 3 && words[0][0] == '(' && words[0][2] == ')' && words[0][1] >= 'A' && words[0][1] <= 'Z' {
+		todo.Priority = todoTxtPriority(words[0][1:2])
+		words = words[1:]
+	}
+	// the creation date cannot be set
+	if isDate(0) {
+		words = words[1:]
+	}
+
+	var title []string
+	for _, word := range words {
+		key, value, _ := strings.Cut(word, ":")
+		switch {
+		case len(word) > 1 && (word[0] == '+' || word[0] == '@'):
+			todo.Tags = append(todo.Tags, word[1:])
+		case key == "due" && value != "":
+			deadline, err := parseImportTime(value)
+			if err != nil {
+				return todo, fmt.Errorf("invalid due: %w", err)
+			}
+			todo.Deadline = deadline
+		case key == "pri" && len(value) == 1:
+			todo.Priority = todoTxtPriority(strings.ToUpper(value))
+		case key == "status" && value != "":
+			todo.Status = strings.ReplaceAll(value, "_", " ")
+		case key == "rec" && value != "":
+			recurrence, err := parseTodoTxtRecurrence(value)
+			if err != nil {
+				return todo, err
+			}
+			todo.Recurrence = recurrence
+		default:
+			title = append(title, word)
+		}
+	}
+	todo.Title = strings.Join(title, " ")
+
+	// monthly rules repeat on the day of the deadline
+	if todo.Recurrence != nil && todo.Recurrence.Frequency == model.FrequencyMonthly && todo.Deadline != nil {
+		todo.Recurrence.MonthDay = todo.Deadline.Day()
+	}
+	return todo, nil
+}
+
+func todoTxtPriority(letter string) string {
+	for priority, l := range todoTxtPriorities {
+		if l == letter {
+			return priority
+		}
+	}
+	return "P3"
+}
+
+// parseTodoTxtRecurrence reads a rec: value such as 3d, 2w or 1m.
+func parseTodoTxtRecurrence(value string) (*model.Recurrence, error) {
+	frequencies := map[byte]string{
+		'd': model.FrequencyDaily,
+		'w': model.FrequencyWeekly,
+		'm': model.FrequencyMonthly,
+	}
+
+	// a leading + means a strict schedule in todo.txt, which is the only kind here
+	value = strings.TrimPrefix(value, "+")
+	if value == "" {
+		return nil, errors.New("invalid rec")
+	}
+	frequency, ok := frequencies[value[len(value)-1]]
+	interval := 1
+	if len(value) > 1 {
+		var err error
+		interval, err = strconv.Atoi(value[:len(value)-1])
+		if err != nil || interval < 1 {
+			ok = false
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid rec %q, expected a count and d, w or m", value)
+	}
+	return &model.Recurrence{Frequency: frequency, Interval: interval}, nil
+}
+
+// parseImportTime reads an RFC 3339 time or a date, taken as its start in UTC.
+func parseImportTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither RFC 3339 nor YYYY-MM-DD", value)
+	}
+	return &t, nil
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+func CreateTodoItem(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	var body model.TodoItemRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	todo, err := dbhelper.GetTodoByID(todoID, userID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch todo")
+		return
+	}
+	if todo == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	var itemID string
+	err = database.Tx(func(tx *sqlx.Tx) error {
+		return withChecklistHistory(tx, auth, todoID, func() error {
+			var err error
+			itemID, err = dbhelper.CreateTodoItem(tx, todoID, body.Title)
+			return err
+		})
+	})
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to create checklist item")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusCreated, map[string]string{
+		"id": itemID,
+	})
+}
+
+func UpdateTodoItem(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	itemID := chi.URLParam(r, "itemID")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	var body model.TodoItemPatch
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	item, err := dbhelper.GetTodoItem(todoID, itemID, userID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch checklist item")
+		return
+	}
+	if item == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "checklist item not found")
+		return
+	}
+
+	var completed bool
+	err = database.Tx(func(tx *sqlx.Tx) error {
+		if err := checkTodoVersion(tx, userID, todoID, ""); err != nil {
+			return err
+		}
+		err := withChecklistHistory(tx, auth, todoID, func() error {
+			return dbhelper.UpdateTodoItem(tx, todoID, itemID, body.Title, body.Done)
+		})
+		if err != nil {
+			return err
+		}
+		completed, err = autoCompleteTodo(tx, auth, todoID)
+		return err
+	})
+	if errors.Is(err, errTodoNotFound) {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to update checklist item")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":        "updated successfully",
+		"todo_completed": completed,
+	})
+}
+
+func DeleteTodoItem(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	itemID := chi.URLParam(r, "itemID")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+
+	item, err := dbhelper.GetTodoItem(todoID, itemID, userID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch checklist item")
+		return
+	}
+	if item == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "checklist item not found")
+		return
+	}
+
+	var completed bool
+	err = database.Tx(func(tx *sqlx.Tx) error {
+		if err := checkTodoVersion(tx, userID, todoID, ""); err != nil {
+			return err
+		}
+		err := withChecklistHistory(tx, auth, todoID, func() error {
+			return dbhelper.DeleteTodoItem(tx, todoID, itemID)
+		})
+		if err != nil {
+			return err
+		}
+		// removing the last open item may finish the checklist
+		completed, err = autoCompleteTodo(tx, auth, todoID)
+		return err
+	})
+	if errors.Is(err, errTodoNotFound) {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to delete checklist item")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":        "deleted successfully",
+		"todo_completed": completed,
+	})
+}
+
+func ReorderTodoItems(w http.ResponseWriter, r *http.Request) {
+	todoID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	var body model.TodoItemOrderRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	todo, err := dbhelper.GetTodoByID(todoID, auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch todo")
+		return
+	}
+	if todo == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "todo not found")
+		return
+	}
+
+	err = database.Tx(func(tx *sqlx.Tx) error {
+		return withChecklistHistory(tx, auth, todoID, func() error {
+			return dbhelper.ReorderTodoItems(tx, todoID, body.ItemIDs)
+		})
+	})
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to reorder checklist")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "reordered successfully")
+}
+
+// autoCompleteTodo marks an auto-completing todo Completed once its whole checklist is done,
+// inside tx, the transaction of the checklist change, which must hold the lock of the todo.
+// A todo that may not be completed any more, e.g. past its deadline, is left as it is.
+func autoCompleteTodo(tx *sqlx.Tx, auth middleware.AuthContext, todoID string) (bool, error) {
+	todo, err := dbhelper.GetTodoTx(tx, todoID, auth.UserID)
+	if err != nil || todo == nil {
+		return false, err
+	}
+	if !todo.AutoComplete || todo.Status == "Completed" {
+		return false, nil
+	}
+
+	done, err := dbhelper.AreTodoItemsDone(tx, todoID)
+	if err != nil || !done {
+		return false, err
+	}
+
+	err = applyTodoStatus(tx, auth, *todo, "Completed")
+	if errors.Is(err, errCompletedAfterDeadline) {
+		return false, nil
+	}
+	return err == nil, err
+}
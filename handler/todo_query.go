@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// maxTodoQueryTerms bounds the size of the SQL a filter query compiles to.
+const maxTodoQueryTerms = 20
+
+var todoStatuses = []string{"Completed", "Not Completed", "Pending", "Overdue"}
+
+var todoPriorities = []string{"P0", "P1", "P2", "P3"}
+
+// todoQueryToken is a word of a filter query. Literal tokens started with a
+// quote and are searched for as they are.
+type todoQueryToken struct {
+	text    string
+	literal bool
+}
+
+// parseTodoQuery parses the ?q= filter language of GET /todos. The query is a
+// list of terms that all have to match, e.g.
+//
+//	status:Pending,"Not Completed" due<2026-11-01 -tag:personal "weekly report"
+//
+// Words without a field, quoted or not, are searched for in titles and
+// descriptions, and a leading "-" negates a term.
+func parseTodoQuery(raw string) ([]model.TodoQueryTerm, error) {
+	tokens, err := splitTodoQuery(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) > maxTodoQueryTerms {
+		return nil, fmt.Errorf("at most %d terms are allowed", maxTodoQueryTerms)
+	}
+
+	terms := make([]model.TodoQueryTerm, 0, len(tokens))
+	for _, token := range tokens {
+		term, err := parseTodoQueryTerm(token)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+func splitTodoQuery(raw string) ([]todoQueryToken, error) {
+	var tokens []todoQueryToken
+	var current strings.Builder
+	started, literal, quoted := false, false, false
+
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			if !started {
+				literal = true
+			}
+			quoted = !quoted
+			started = true
+		case unicode.IsSpace(r) && !quoted:
+			if started {
+				tokens = append(tokens, todoQueryToken{text: current.String(), literal: literal})
+				current.Reset()
+				started, literal = false, false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if started {
+		tokens = append(tokens, todoQueryToken{text: current.String(), literal: literal})
+	}
+	return tokens, nil
+}
+
+func parseTodoQueryTerm(token todoQueryToken) (model.TodoQueryTerm, error) {
+	text := token.text
+	negate := false
+	if !token.literal && len(text) > 1 && strings.HasPrefix(text, "-") {
+		negate = true
+		text = text[1:]
+	}
+
+	end := strings.IndexAny(text, ":<>")
+	if token.literal || end <= 0 || !isTodoQueryField(text[:end]) {
+		if text == "" {
+			return model.TodoQueryTerm{}, errors.New("empty search text")
+		}
+		return model.TodoQueryTerm{Field: "text", Op: ":", Values: []string{text}, Negate: negate}, nil
+	}
+
+	term := model.TodoQueryTerm{Field: strings.ToLower(text[:end]), Negate: negate}
+	rest := text[end:]
+	term.Op = rest[:1]
+	if strings.HasPrefix(rest, "<=") || strings.HasPrefix(rest, ">=") {
+		term.Op = rest[:2]
+	}
+	value := rest[len(term.Op):]
+	if value == "" {
+		return model.TodoQueryTerm{}, fmt.Errorf("missing value for %s", term.Field)
+	}
+
+	if term.Op != ":" && term.Field != "due" && term.Field != "created" {
+		return model.TodoQueryTerm{}, fmt.Errorf("%s does not support %s", term.Field, term.Op)
+	}
+
+	var err error
+	switch term.Field {
+	case "status":
+		term.Values, err = parseTodoQueryChoices(term.Field, value, todoStatuses)
+	case "priority":
+		term.Values, err = parseTodoQueryChoices(term.Field, value, todoPriorities)
+	case "project":
+		term.Values = strings.Split(value, ",")
+		for _, projectID := range term.Values {
+			if validate.Var(projectID, "uuid") != nil {
+				return model.TodoQueryTerm{}, fmt.Errorf("invalid project %q", projectID)
+			}
+		}
+	case "tag":
+		term.Values = normalizeTags(strings.Split(value, ","))
+		if len(term.Values) == 0 {
+			return model.TodoQueryTerm{}, errors.New("missing value for tag")
+		}
+	case "text":
+		term.Values = []string{value}
+	case "is":
+		err = expectTodoQueryValue(term.Field, value, "overdue")
+		term.Values = []string{"overdue"}
+	case "has", "no":
+		err = expectTodoQueryValue(term.Field, value, "deadline")
+		term.Values = []string{"deadline"}
+	case "due", "created":
+		term.Values, err = parseTodoQueryDates(term, value)
+	}
+	if err != nil {
+		return model.TodoQueryTerm{}, err
+	}
+	return term, nil
+}
+
+func isTodoQueryField(field string) bool {
+	switch strings.ToLower(field) {
+	case "status", "priority", "project", "tag", "text", "is", "has", "no", "due", "created":
+		return true
+	}
+	return false
+}
+
+// parseTodoQueryChoices reads a comma separated set of allowed values, in any case.
+func parseTodoQueryChoices(field, value string, allowed []string) ([]string, error) {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		found := ""
+		for _, choice := range allowed {
+			if strings.EqualFold(strings.TrimSpace(part), choice) {
+				found = choice
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("invalid %s %q", field, part)
+		}
+		values = append(values, found)
+	}
+	return values, nil
+}
+
+func expectTodoQueryValue(field, value, expected string) error {
+	if !strings.EqualFold(value, expected) {
+		return fmt.Errorf("invalid %s %q, expected %s:%s", field, value, field, expected)
+	}
+	return nil
+}
+
+// parseTodoQueryDates reads the value of a due or created term: a date, or with
+// ":" also a FROM..TO range of dates, today, this-week and, for due, overdue.
+func parseTodoQueryDates(term model.TodoQueryTerm, value string) ([]string, error) {
+	if term.Op == ":" {
+		switch keyword := strings.ToLower(value); keyword {
+		case "today", "this-week":
+			return []string{keyword}, nil
+		case "overdue":
+			if term.Field == "due" {
+				return []string{keyword}, nil
+			}
+		}
+	}
+
+	dates := []string{value}
+	if from, to, ok := strings.Cut(value, ".."); ok && term.Op == ":" {
+		dates = []string{from, to}
+	}
+
+	var parsed []time.Time
+	for _, date := range dates {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s date %q, expected YYYY-MM-DD", term.Field, date)
+		}
+		parsed = append(parsed, t)
+	}
+	if len(parsed) == 2 && parsed[1].Before(parsed[0]) {
+		return nil, fmt.Errorf("invalid %s range %q", term.Field, value)
+	}
+	return dates, nil
+}
@@ -0,0 +1,438 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/importer"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// maxImportSize bounds the upload of an import.
+	maxImportSize = 10 << 20
+	// maxImportRows bounds the todos a single import may create.
+	maxImportRows = 10000
+	// importBatchSize is how many todos are inserted with one statement.
+	importBatchSize = 500
+	// importPreviewSize is how many todos a dry run shows.
+	importPreviewSize = 20
+	// exportFlushEvery sends the rows written so far every that many todos.
+	exportFlushEvery = 100
+	// maxProjectName is the longest name a project may have.
+	maxProjectName = 100
+)
+
+// todoFileTypes describes the file an export is sent as.
+var todoFileTypes = map[string]struct {
+	contentType string
+	extension   string
+}{
+	model.FormatCSV:     {"text/csv; charset=utf-8", ".csv"},
+	model.FormatJSON:    {"application/json", ".json"},
+	model.FormatTodoTxt: {"text/plain; charset=utf-8", ".txt"},
+}
+
+// ExportTodos streams the todos of the user, narrowed by the filters of
+// GET /todos, as ?format=csv, json or todotxt. Todos are read and sent a few
+// at a time, so large accounts are never held in memory.
+func ExportTodos(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = model.FormatCSV
+	}
+	fileType, ok := todoFileTypes[format]
+	if !ok {
+		util.RespondError(w, http.StatusBadRequest, nil, "format must be csv, json or todotxt")
+		return
+	}
+
+	filter, err := parseTodoFilter(query)
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	filter.Query, err = parseTodoQuery(query.Get("q"))
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, "invalid q: "+err.Error())
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	writer := newTodoWriter(format, w)
+	exported := 0
+
+	startExport := func() {
+		w.Header().Set("Content-Type", fileType.contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="todos`+fileType.extension+`"`)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	err = dbhelper.ExportTodos(auth.UserID, filter, func(todo model.Todo) error {
+		if exported == 0 {
+			startExport()
+		}
+		if err := writer.Write(todo); err != nil {
+			return err
+		}
+
+		exported++
+		if exported%exportFlushEvery != 0 {
+			return nil
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if exported == 0 {
+			util.RespondError(w, http.StatusInternalServerError, err, "failed to export todos")
+			return
+		}
+		// the response has started, so the client only sees it cut short
+		log.Printf("export of %s failed after %d todos: %v", auth.UserID, exported, err)
+		return
+	}
+
+	if exported == 0 {
+		startExport()
+	}
+	if err := writer.Close(); err != nil {
+		log.Printf("export of %s failed: %v", auth.UserID, err)
+	}
+}
+
+// ImportTodos creates todos from a multipart upload in the file field, in the
+// ?format= given or told by the extension of the file. Besides this service's
+// own formats, the exports of Todoist, Trello and Microsoft To Do are read by
+// the adapters of package importer. Projects are matched by name or created,
+// and the project form field names the one of the rows that have none.
+//
+// Every row is checked first and nothing is imported when one is invalid; with
+// ?dry_run=true the report is only previewed. The todos are inserted in
+// batches, all in one transaction.
+func ImportTodos(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	userID := auth.UserID
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "multipart upload with a file field is required")
+		return
+	}
+	defer file.Close()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = r.FormValue("format")
+	}
+	if format == "" {
+		format = importFormat(header.Filename)
+	}
+	readTodos, ok := todoReaders[format]
+	if !ok {
+		adapter, found := importer.Lookup(format)
+		if !found {
+			formats := append([]string{model.FormatCSV, model.FormatJSON, model.FormatTodoTxt}, importer.Names()...)
+			util.RespondError(w, http.StatusBadRequest, nil, "unknown format, use ?format= with one of "+strings.Join(formats, ", "))
+			return
+		}
+		readTodos = adapter.Read
+	}
+
+	imported, err := readTodos(file, maxImportRows)
+	if errors.Is(err, model.ErrTooManyImportRows) {
+		util.RespondError(w, http.StatusRequestEntityTooLarge, nil, fmt.Sprintf("at most %d todos can be imported at once", maxImportRows))
+		return
+	}
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to read file")
+		return
+	}
+	total := len(imported.Rows) + len(imported.Errors)
+
+	rows, checkErrors, err := checkImportedTodos(userID, imported.Rows, r.FormValue("project"))
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to check todos")
+		return
+	}
+
+	report := newImportReport(format, dryRun, total, rows)
+	report.Errors = sortedRowErrors(append(imported.Errors, checkErrors...))
+	report.Skipped = sortedRowErrors(imported.Skipped)
+	projectNames := importedProjects(rows)
+
+	if dryRun {
+		projectIDs, err := dbhelper.GetProjectIDsByName(userID, projectNames)
+		if err != nil {
+			util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch projects")
+			return
+		}
+		for _, name := range projectNames {
+			if _, ok := projectIDs[strings.ToLower(name)]; ok {
+				report.ProjectsMatched = append(report.ProjectsMatched, name)
+			} else {
+				report.ProjectsCreated = append(report.ProjectsCreated, name)
+			}
+		}
+
+		report.Preview = make([]model.Todo, 0, importPreviewSize)
+		for i := 0; i < len(rows) && i < importPreviewSize; i++ {
+			report.Preview = append(report.Preview, rows[i].Todo)
+		}
+		util.RespondJSON(w, http.StatusOK, report)
+		return
+	}
+
+	if len(report.Errors) > 0 {
+		util.RespondJSON(w, http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	todoIDs := make([]string, 0, len(rows))
+	err = database.Tx(func(tx *sqlx.Tx) error {
+		projectIDs, created, err := dbhelper.CreateProjects(tx, userID, projectNames)
+		if err != nil {
+			return err
+		}
+		for _, name := range projectNames {
+			if !slices.Contains(created, name) {
+				report.ProjectsMatched = append(report.ProjectsMatched, name)
+			}
+		}
+		report.ProjectsCreated = append(report.ProjectsCreated, created...)
+
+		for start := 0; start < len(rows); start += importBatchSize {
+			batch := rows[start:min(start+importBatchSize, len(rows))]
+
+			todos := make([]model.Todo, len(batch))
+			for i, row := range batch {
+				todos[i] = row.Todo
+				if row.Project != "" {
+					projectID := projectIDs[strings.ToLower(row.Project)]
+					todos[i].ProjectID = &projectID
+				}
+			}
+
+			batchIDs, err := dbhelper.CreateTodos(tx, userID, todos)
+			if err != nil {
+				return err
+			}
+
+			var itemTodoIDs, itemTitles []string
+			var itemsDone []bool
+			for i, row := range batch {
+				for _, item := range row.Checklist {
+					itemTodoIDs = append(itemTodoIDs, batchIDs[i])
+					itemTitles = append(itemTitles, item.Title)
+					itemsDone = append(itemsDone, item.Done)
+				}
+			}
+			if len(itemTodoIDs) > 0 {
+				if err := dbhelper.CreateTodoItems(tx, itemTodoIDs, itemTitles, itemsDone); err != nil {
+					return err
+				}
+			}
+
+			created, err := dbhelper.GetTodosTx(tx, userID, batchIDs)
+			if err != nil {
+				return err
+			}
+			if err := recordTodosCreated(tx, auth, created); err != nil {
+				return err
+			}
+			todoIDs = append(todoIDs, batchIDs...)
+		}
+		return nil
+	})
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to import todos")
+		return
+	}
+
+	report.Imported = len(todoIDs)
+	report.IDs = todoIDs
+	util.RespondJSON(w, http.StatusCreated, report)
+}
+
+func importFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return model.FormatCSV
+	case ".json":
+		return model.FormatJSON
+	case ".txt":
+		return model.FormatTodoTxt
+	}
+	return ""
+}
+
+// checkImportedTodos fills in the defaults of the rows read from an import and
+// checks them the way POST /todos does, returning the valid ones in order. Rows
+// without a project go to defaultProject, if any.
+func checkImportedTodos(userID string, rows []model.ImportRow, defaultProject string) ([]model.ImportRow, []model.ImportRowError, error) {
+	checked := make([]model.ImportRow, 0, len(rows))
+	var rowErrors []model.ImportRowError
+	// projects are looked up once however many rows use them
+	projects := map[string]error{}
+
+	for _, row := range rows {
+		todo := &row.Todo
+		if todo.Status == "" {
+			todo.Status = "Not Completed"
+		}
+		// only the server marks todos overdue; the overdue job marks them again
+		if todo.Status == "Overdue" {
+			todo.Status = "Pending"
+		}
+		if todo.Priority == "" {
+			todo.Priority = "P3"
+		}
+		todo.Tags = normalizeTags(todo.Tags)
+		if todo.Status != "Completed" {
+			todo.CompletedAt = nil
+		}
+		if todo.ProjectID == nil {
+			row.Project = importedProjectName(row.Project, defaultProject)
+		} else {
+			row.Project = ""
+		}
+
+		err := checkImportedTodo(row)
+		if err == nil && todo.ProjectID != nil {
+			projectErr, seen := projects[*todo.ProjectID]
+			if !seen {
+				status, message, lookupErr := lookupProject(userID, todo.ProjectID)
+				if status == http.StatusInternalServerError {
+					return nil, nil, lookupErr
+				}
+				if status != 0 {
+					projectErr = errors.New(message)
+				}
+				projects[*todo.ProjectID] = projectErr
+			}
+			err = projectErr
+		}
+
+		if err != nil {
+			rowErrors = append(rowErrors, model.ImportRowError{Row: row.Row, Error: err.Error()})
+			continue
+		}
+		checked = append(checked, row)
+	}
+	return checked, rowErrors, nil
+}
+
+func checkImportedTodo(row model.ImportRow) error {
+	todo := row.Todo
+	if strings.TrimSpace(todo.Title) == "" {
+		return errors.New("title is required")
+	}
+	if !slices.Contains(todoStatuses, todo.Status) {
+		return fmt.Errorf("invalid status %q", todo.Status)
+	}
+	if err := validate.Struct(todo); err != nil {
+		return err
+	}
+	if todo.Recurrence != nil && todo.Deadline == nil {
+		return errors.New("recurring todo needs a deadline")
+	}
+	for i, item := range row.Checklist {
+		if err := validate.Struct(model.TodoItemRequest{Title: strings.TrimSpace(item.Title)}); err != nil {
+			return fmt.Errorf("checklist item %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// importedProjectName is the name of the project a row is imported into, cut
+// to the length a project name may have.
+func importedProjectName(name, defaultProject string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = strings.TrimSpace(defaultProject)
+	}
+	if runes := []rune(name); len(runes) > maxProjectName {
+		name = strings.TrimSpace(string(runes[:maxProjectName]))
+	}
+	return name
+}
+
+// importedProjects lists the projects the rows are imported into, each once.
+func importedProjects(rows []model.ImportRow) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, row := range rows {
+		key := strings.ToLower(row.Project)
+		if row.Project == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, row.Project)
+	}
+	return names
+}
+
+// newImportReport counts what the valid rows of an import hold.
+func newImportReport(format string, dryRun bool, total int, rows []model.ImportRow) model.ImportReport {
+	report := model.ImportReport{
+		Format:          format,
+		DryRun:          dryRun,
+		Total:           total,
+		Valid:           len(rows),
+		Tags:            []string{},
+		ProjectsMatched: []string{},
+		ProjectsCreated: []string{},
+	}
+
+	var tags []string
+	for _, row := range rows {
+		if row.Todo.Status == "Completed" {
+			report.Completed++
+		}
+		report.ChecklistItems += len(row.Checklist)
+		tags = append(tags, row.Todo.Tags...)
+	}
+	report.Tags = append(report.Tags, normalizeTags(tags)...)
+	return report
+}
+
+// sortedRowErrors orders the errors of an import by row, never returning nil.
+func sortedRowErrors(rowErrors []model.ImportRowError) []model.ImportRowError {
+	slices.SortStableFunc(rowErrors, func(a, b model.ImportRowError) int {
+		return a.Row - b.Row
+	})
+	if rowErrors == nil {
+		rowErrors = []model.ImportRowError{}
+	}
+	return rowErrors
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/Shubhouy1/todo-app/database"
 	"github.com/Shubhouy1/todo-app/database/dbhelper"
@@ -93,12 +94,23 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
 		return
 	}
+	// deletedAt is set when the account is deleted and reactivation was not asked for
+	var deletedAt *time.Time
 	txErr := database.Tx(func(tx *sqlx.Tx) error {
-		var err error
-		userID, err = dbhelper.GetUserByEmail(tx, body.Email, body.Password)
+		user, err := dbhelper.GetUserByEmail(tx, body.Email, body.Password)
 		if err != nil {
 			return err
 		}
+		userID = user.ID
+		if user.ArchivedAt != nil {
+			if !body.Reactivate {
+				deletedAt = user.ArchivedAt
+				return nil
+			}
+			if err := dbhelper.ReactivateUser(tx, userID); err != nil {
+				return err
+			}
+		}
 		sessionID = util.GenerateSessionID()
 		if err := dbhelper.CreateUserSession(tx, userID, sessionID); err != nil {
 			return err
@@ -109,6 +121,13 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		util.RespondError(w, http.StatusUnauthorized, txErr, "invalid credentials")
 		return
 	}
+	if deletedAt != nil {
+		util.RespondJSON(w, http.StatusConflict, map[string]interface{}{
+			"message":    `account is deleted and will be erased, log in with "reactivate": true to restore it`,
+			"deleted_at": deletedAt,
+		})
+		return
+	}
 	token, err := util.GenerateJWT(userID, fmt.Sprintf("%d", sessionID))
 	if err != nil {
 		util.RespondError(w, http.StatusInternalServerError, err, "failed to generate token")
@@ -153,6 +172,10 @@ func GetUserDetail(w http.ResponseWriter, r *http.Request) {
 
 	util.RespondJSON(w, http.StatusOK, user)
 }
+
+// DeleteUser deletes the account of the user along with their sessions and
+// todos. It is kept for a grace period, during which logging in again with
+// "reactivate" restores it, and then erased for good by the purge job.
 func DeleteUser(w http.ResponseWriter, r *http.Request) {
 	auth, ok := middleware.GetAuthContext(r)
 	if !ok {
@@ -186,6 +209,6 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	util.RespondJSON(w, http.StatusOK, map[string]string{
-		"message": "user deleted successfully",
+		"message": "user deleted successfully, logging in again before it is erased restores it",
 	})
 }
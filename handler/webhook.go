@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/middleware"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateWebhook subscribes a URL to todo events. The URL must resolve to public
+// addresses only. The response carries the secret the deliveries are signed
+// with; it is not shown again.
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	var body model.WebhookRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	if err := util.CheckPublicURL(r.Context(), body.URL); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "url must point to a public address")
+		return
+	}
+
+	secret, err := util.RandomSecret(32)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to generate secret")
+		return
+	}
+
+	webhookID, err := dbhelper.CreateWebhook(auth.UserID, body.URL, secret, body.Events)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to create webhook")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusCreated, map[string]string{
+		"id":     webhookID,
+		"secret": secret,
+	})
+}
+
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	webhooks, err := dbhelper.GetWebhooks(auth.UserID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch webhooks")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, webhooks)
+}
+
+func GetWebhookByID(w http.ResponseWriter, r *http.Request) {
+	webhookID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(webhookID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "webhook not found")
+		return
+	}
+
+	webhook, err := dbhelper.GetWebhookByID(auth.UserID, webhookID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch webhook")
+		return
+	}
+	if webhook == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "webhook not found")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, webhook)
+}
+
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(webhookID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "webhook not found")
+		return
+	}
+
+	userID := auth.UserID
+
+	var body model.WebhookRequest
+	if err := util.ParseBody(r, &body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "invalid body")
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "failed to validate request body")
+		return
+	}
+
+	webhook, err := dbhelper.GetWebhookByID(userID, webhookID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch webhook")
+		return
+	}
+	if webhook == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "webhook not found")
+		return
+	}
+
+	if err := util.CheckPublicURL(r.Context(), body.URL); err != nil {
+		util.RespondError(w, http.StatusBadRequest, err, "url must point to a public address")
+		return
+	}
+
+	if err := dbhelper.UpdateWebhook(userID, webhookID, body.URL, body.Events, body.Active); err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to update webhook")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "updated successfully")
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(webhookID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "webhook not found")
+		return
+	}
+
+	webhook, err := dbhelper.GetWebhookByID(auth.UserID, webhookID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch webhook")
+		return
+	}
+	if webhook == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "webhook not found")
+		return
+	}
+
+	if err := dbhelper.DeleteWebhook(auth.UserID, webhookID); err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to delete webhook")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, "deleted successfully")
+}
+
+// GetWebhookDeliveries lists the deliveries of a webhook with the outcome of
+// their latest attempt, newest first.
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID := chi.URLParam(r, "id")
+	auth, ok := middleware.GetAuthContext(r)
+	if !ok {
+		util.RespondError(w, http.StatusUnauthorized, nil, "unauthorized")
+		return
+	}
+
+	if validate.Var(webhookID, "uuid") != nil {
+		util.RespondError(w, http.StatusNotFound, nil, "webhook not found")
+		return
+	}
+
+	page, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		util.RespondError(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	webhook, err := dbhelper.GetWebhookByID(auth.UserID, webhookID)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch webhook")
+		return
+	}
+	if webhook == nil {
+		util.RespondError(w, http.StatusNotFound, nil, "webhook not found")
+		return
+	}
+
+	deliveries, err := dbhelper.GetWebhookDeliveries(webhookID, auth.UserID, limit, (page-1)*limit)
+	if err != nil {
+		util.RespondError(w, http.StatusInternalServerError, err, "failed to fetch deliveries")
+		return
+	}
+
+	util.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"page":  page,
+		"limit": limit,
+		"data":  deliveries,
+	})
+}
@@ -0,0 +1,165 @@
+// Package importer reads the export files of other todo apps into the rows of
+// an import, with one adapter per app. The rows go through the same checks and
+// inserts as the imports of this service's own formats.
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// Adapter reads the export file of one app.
+type Adapter interface {
+	// Name is the ?format= of POST /todos/import the adapter reads.
+	Name() string
+	// Read reads at most limit rows, returning model.ErrTooManyImportRows
+	// once the file has more.
+	Read(r io.Reader, limit int) (model.ImportFile, error)
+}
+
+var adapters = []Adapter{
+	Todoist{},
+	Trello{},
+	MicrosoftToDo{},
+}
+
+// Lookup returns the adapter with the given name.
+func Lookup(name string) (Adapter, bool) {
+	for _, adapter := range adapters {
+		if adapter.Name() == name {
+			return adapter, true
+		}
+	}
+	return nil, false
+}
+
+// Names lists the names of every adapter.
+func Names() []string {
+	names := make([]string, len(adapters))
+	for i, adapter := range adapters {
+		names[i] = adapter.Name()
+	}
+	return names
+}
+
+// peekJSON reports whether the file read by br starts like a JSON document.
+func peekJSON(br *bufio.Reader) bool {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return false
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		// a UTF-8 byte order mark is not part of the document
+		if b == 0xEF {
+			if bom, err := br.Peek(2); err == nil && bom[0] == 0xBB && bom[1] == 0xBF {
+				br.Discard(2)
+				continue
+			}
+		}
+		br.UnreadByte()
+		return b == '{' || b == '['
+	}
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseTime reads the dates and times found in exports. Values without an
+// offset are read in zone, an IANA name, falling back to UTC for unknown ones.
+func parseTime(value, zone string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	location := time.UTC
+	if zone != "" {
+		if loaded, err := time.LoadLocation(zone); err == nil {
+			location = loaded
+		}
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid date %q", value)
+}
+
+var everyPattern = regexp.MustCompile(`^every\s+(?:(\d+)\s+)?(day|week|month)s?$`)
+
+// parseEvery reads the simple recurrences written in words, such as "every day",
+// "every 2 weeks" or "monthly". Anything else is not understood and returns nil.
+func parseEvery(text string) *model.Recurrence {
+	text = strings.ToLower(strings.TrimSpace(text))
+	switch text {
+	case "daily":
+		text = "every day"
+	case "weekly":
+		text = "every week"
+	case "monthly":
+		text = "every month"
+	}
+
+	match := everyPattern.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+
+	interval := 1
+	if match[1] != "" {
+		interval, _ = strconv.Atoi(match[1])
+	}
+	if interval < 1 || interval > 365 {
+		return nil
+	}
+
+	frequency := map[string]string{
+		"day":   model.FrequencyDaily,
+		"week":  model.FrequencyWeekly,
+		"month": model.FrequencyMonthly,
+	}[match[2]]
+	return &model.Recurrence{Frequency: frequency, Interval: interval}
+}
+
+// finishRecurrence completes a recurrence read from an export with what the
+// deadline tells, dropping it when there is no deadline to repeat.
+func finishRecurrence(todo *model.Todo) {
+	if todo.Recurrence == nil {
+		return
+	}
+	if todo.Deadline == nil {
+		todo.Recurrence = nil
+		return
+	}
+	if todo.Recurrence.Frequency == model.FrequencyMonthly && todo.Recurrence.MonthDay == 0 {
+		todo.Recurrence.MonthDay = todo.Deadline.Day()
+	}
+}
+
+// appendNote adds a paragraph to the description of a todo.
+func appendNote(todo *model.Todo, note string) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return
+	}
+	if todo.Description != "" {
+		todo.Description += "\n\n"
+	}
+	todo.Description += note
+}
@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// MicrosoftToDo reads the tasks of Microsoft To Do as the Graph API returns
+// them: an object of lists with their tasks, a page of lists, or a page of the
+// tasks of one list. A list becomes the project of its tasks, categories become
+// tags and checklist items the checklist. Recurrences other than daily, weekly
+// and on a day of the month are kept in the description.
+type MicrosoftToDo struct{}
+
+func (MicrosoftToDo) Name() string {
+	return "microsoft-todo"
+}
+
+type msTodoList struct {
+	DisplayName string       `json:"displayName"`
+	Tasks       []msTodoTask `json:"tasks"`
+}
+
+type msTodoDateTime struct {
+	DateTime string `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
+}
+
+type msTodoTask struct {
+	Title      string `json:"title"`
+	Status     string `json:"status"`
+	Importance string `json:"importance"`
+	Body       *struct {
+		Content     string `json:"content"`
+		ContentType string `json:"contentType"`
+	} `json:"body"`
+	DueDateTime       *msTodoDateTime `json:"dueDateTime"`
+	CompletedDateTime *msTodoDateTime `json:"completedDateTime"`
+	Categories        []string        `json:"categories"`
+	ChecklistItems    []struct {
+		DisplayName string `json:"displayName"`
+		IsChecked   bool   `json:"isChecked"`
+	} `json:"checklistItems"`
+	Recurrence *struct {
+		Pattern struct {
+			Type       string   `json:"type"`
+			Interval   int      `json:"interval"`
+			DaysOfWeek []string `json:"daysOfWeek"`
+			DayOfMonth int      `json:"dayOfMonth"`
+		} `json:"pattern"`
+	} `json:"recurrence"`
+}
+
+// msTodoValue is an entry of a Graph page, which is either a list or a task.
+type msTodoValue struct {
+	msTodoTask
+	DisplayName string       `json:"displayName"`
+	Tasks       []msTodoTask `json:"tasks"`
+}
+
+var msTodoImportance = map[string]string{
+	"high":   "P1",
+	"normal": "P3",
+	"low":    "P3",
+}
+
+var msTodoWeekdays = map[string]string{
+	"monday":    "MO",
+	"tuesday":   "TU",
+	"wednesday": "WE",
+	"thursday":  "TH",
+	"friday":    "FR",
+	"saturday":  "SA",
+	"sunday":    "SU",
+}
+
+func (m MicrosoftToDo) Read(r io.Reader, limit int) (model.ImportFile, error) {
+	var export struct {
+		Lists []msTodoList  `json:"lists"`
+		Value []msTodoValue `json:"value"`
+	}
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return model.ImportFile{}, fmt.Errorf("invalid Microsoft To Do JSON: %w", err)
+	}
+
+	lists := export.Lists
+	var tasks []msTodoTask
+	for _, value := range export.Value {
+		if value.Tasks != nil {
+			lists = append(lists, msTodoList{DisplayName: value.DisplayName, Tasks: value.Tasks})
+			continue
+		}
+		tasks = append(tasks, value.msTodoTask)
+	}
+	if tasks != nil {
+		lists = append(lists, msTodoList{Tasks: tasks})
+	}
+	if lists == nil {
+		return model.ImportFile{}, errors.New("not a Microsoft To Do export, it has no lists or tasks")
+	}
+
+	var file model.ImportFile
+	for _, list := range lists {
+		for _, task := range list.Tasks {
+			if file.Full(limit) {
+				return model.ImportFile{}, model.ErrTooManyImportRows
+			}
+			row := len(file.Rows) + len(file.Errors) + 1
+			todo, err := m.todo(task)
+			if err != nil {
+				file.Errors = append(file.Errors, model.ImportRowError{Row: row, Error: err.Error()})
+				continue
+			}
+
+			importRow := model.ImportRow{Row: row, Todo: todo, Project: list.DisplayName}
+			for _, item := range task.ChecklistItems {
+				importRow.Checklist = append(importRow.Checklist, model.ImportChecklistItem{
+					Title: item.DisplayName,
+					Done:  item.IsChecked,
+				})
+			}
+			file.Rows = append(file.Rows, importRow)
+		}
+	}
+	return file, nil
+}
+
+func (MicrosoftToDo) todo(task msTodoTask) (model.Todo, error) {
+	todo := model.Todo{
+		Title:    task.Title,
+		Status:   "Not Completed",
+		Priority: msTodoImportance[strings.ToLower(task.Importance)],
+		Tags:     task.Categories,
+	}
+	switch task.Status {
+	case "completed":
+		todo.Status = "Completed"
+	case "inProgress":
+		todo.Status = "Pending"
+	}
+
+	if task.Body != nil {
+		content := task.Body.Content
+		if strings.EqualFold(task.Body.ContentType, "html") {
+			content = stripHTML(content)
+		}
+		appendNote(&todo, content)
+	}
+
+	var err error
+	if task.DueDateTime != nil {
+		todo.Deadline, err = parseTime(task.DueDateTime.DateTime, task.DueDateTime.TimeZone)
+		if err != nil {
+			return model.Todo{}, err
+		}
+	}
+	if task.CompletedDateTime != nil && todo.Status == "Completed" {
+		todo.CompletedAt, _ = parseTime(task.CompletedDateTime.DateTime, task.CompletedDateTime.TimeZone)
+	}
+
+	if task.Recurrence != nil {
+		pattern := task.Recurrence.Pattern
+		recurrence := &model.Recurrence{Interval: max(pattern.Interval, 1)}
+		switch pattern.Type {
+		case "daily":
+			recurrence.Frequency = model.FrequencyDaily
+		case "weekly":
+			recurrence.Frequency = model.FrequencyWeekly
+			for _, day := range pattern.DaysOfWeek {
+				if weekday, ok := msTodoWeekdays[strings.ToLower(day)]; ok {
+					recurrence.Weekdays = append(recurrence.Weekdays, weekday)
+				}
+			}
+		case "absoluteMonthly":
+			recurrence.Frequency = model.FrequencyMonthly
+			recurrence.MonthDay = pattern.DayOfMonth
+		default:
+			recurrence = nil
+			appendNote(&todo, "Microsoft To Do recurrence: "+pattern.Type)
+		}
+		todo.Recurrence = recurrence
+		finishRecurrence(&todo)
+	}
+	return todo, nil
+}
+
+var (
+	htmlBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</li>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// stripHTML turns the HTML body of a task into plain text.
+func stripHTML(content string) string {
+	content = htmlBreakPattern.ReplaceAllString(content, "\n")
+	content = htmlTagPattern.ReplaceAllString(content, "")
+	content = html.UnescapeString(content)
+
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,290 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// Todoist reads the CSV export of a Todoist project and the JSON of its API,
+// where items, or tasks, come with the projects they belong to.
+//
+// Labels become tags, subtasks become the checklist of their top-level task,
+// and comments in a CSV are added to the description of their task. Dates that
+// are written in words, like "every monday", cannot be read and are kept in
+// the description instead, as are recurrences other than every N days, weeks
+// or months. A CSV names no project, so its tasks go to the project the import
+// asks for, if any.
+type Todoist struct{}
+
+func (Todoist) Name() string {
+	return "todoist"
+}
+
+func (t Todoist) Read(r io.Reader, limit int) (model.ImportFile, error) {
+	br := bufio.NewReader(r)
+	if peekJSON(br) {
+		return t.readJSON(br, limit)
+	}
+	return t.readCSV(br, limit)
+}
+
+// todoistCSVPriorities maps the PRIORITY column of a CSV, where 1 is p1, the highest.
+var todoistCSVPriorities = map[string]string{
+	"1": "P0",
+	"2": "P1",
+	"3": "P2",
+	"4": "P3",
+}
+
+func (Todoist) readCSV(r io.Reader, limit int) (model.ImportFile, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return model.ImportFile{}, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["CONTENT"]; !ok {
+		return model.ImportFile{}, errors.New("not a Todoist CSV, it has no CONTENT column")
+	}
+
+	var file model.ImportFile
+	// current is the last top-level task, which subtasks and comments belong to
+	current := -1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			if file.Full(limit) {
+				return model.ImportFile{}, model.ErrTooManyImportRows
+			}
+			file.Errors = append(file.Errors, model.ImportRowError{Row: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return model.ImportFile{}, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		switch strings.ToLower(field("TYPE")) {
+		case "", "task":
+		case "note":
+			if current >= 0 {
+				appendNote(&file.Rows[current].Todo, field("CONTENT"))
+			}
+			continue
+		case "section":
+			file.Skipped = append(file.Skipped, model.ImportRowError{Row: line, Error: "sections are not imported"})
+			continue
+		default:
+			file.Skipped = append(file.Skipped, model.ImportRowError{Row: line, Error: "unknown type " + field("TYPE")})
+			continue
+		}
+
+		title, labels := todoistContent(field("CONTENT"))
+		if indent, _ := strconv.Atoi(field("INDENT")); indent > 1 && current >= 0 {
+			file.Rows[current].Checklist = append(file.Rows[current].Checklist, model.ImportChecklistItem{Title: title})
+			continue
+		}
+
+		todo := model.Todo{
+			Title:       title,
+			Description: field("DESCRIPTION"),
+			Status:      "Not Completed",
+			Priority:    todoistCSVPriorities[field("PRIORITY")],
+			Tags:        labels,
+		}
+		todoistDate(&todo, field("DATE"), field("TIMEZONE"))
+
+		if file.Full(limit) {
+			return model.ImportFile{}, model.ErrTooManyImportRows
+		}
+		file.Rows = append(file.Rows, model.ImportRow{Row: line, Todo: todo})
+		current = len(file.Rows) - 1
+	}
+	return file, nil
+}
+
+// todoistContent splits the CONTENT of a CSV task into its title and the
+// @labels written inline.
+func todoistContent(content string) (string, []string) {
+	var title []string
+	var labels []string
+	for _, word := range strings.Fields(content) {
+		if len(word) > 1 && word[0] == '@' {
+			labels = append(labels, word[1:])
+			continue
+		}
+		title = append(title, word)
+	}
+	return strings.Join(title, " "), labels
+}
+
+// todoistDate sets the deadline and recurrence of a todo from a Todoist date,
+// which is a date, a time or words, keeping what cannot be read in the description.
+func todoistDate(todo *model.Todo, date, zone string) {
+	if date == "" {
+		return
+	}
+	if deadline, err := parseTime(date, zone); err == nil {
+		todo.Deadline = deadline
+		return
+	}
+	appendNote(todo, "Todoist date: "+date)
+}
+
+type todoistExport struct {
+	Projects []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"projects"`
+	Items []todoistItem `json:"items"`
+	Tasks []todoistItem `json:"tasks"`
+}
+
+type todoistItem struct {
+	ID          string   `json:"id"`
+	Content     string   `json:"content"`
+	Description string   `json:"description"`
+	ProjectID   string   `json:"project_id"`
+	ParentID    *string  `json:"parent_id"`
+	Priority    int      `json:"priority"`
+	Labels      []string `json:"labels"`
+	Due         *struct {
+		Date        string `json:"date"`
+		Datetime    string `json:"datetime"`
+		Timezone    string `json:"timezone"`
+		String      string `json:"string"`
+		IsRecurring bool   `json:"is_recurring"`
+	} `json:"due"`
+	Checked     bool    `json:"checked"`
+	IsCompleted bool    `json:"is_completed"`
+	IsDeleted   bool    `json:"is_deleted"`
+	CompletedAt *string `json:"completed_at"`
+}
+
+// todoistAPIPriorities maps the priority of the API, where 4 is p1, the highest.
+var todoistAPIPriorities = map[int]string{
+	4: "P0",
+	3: "P1",
+	2: "P2",
+	1: "P3",
+}
+
+func (Todoist) readJSON(r io.Reader, limit int) (model.ImportFile, error) {
+	var export todoistExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return model.ImportFile{}, fmt.Errorf("invalid Todoist JSON: %w", err)
+	}
+
+	items := append(export.Items, export.Tasks...)
+	if len(items) == 0 && len(export.Projects) == 0 {
+		return model.ImportFile{}, errors.New("not a Todoist export, it has no items or tasks")
+	}
+
+	projects := make(map[string]string, len(export.Projects))
+	for _, project := range export.Projects {
+		projects[project.ID] = project.Name
+	}
+	parents := make(map[string]string, len(items))
+	for _, item := range items {
+		if item.ParentID != nil {
+			parents[item.ID] = *item.ParentID
+		}
+	}
+	// subtasks of subtasks belong to the checklist of their top-level task
+	topLevel := func(id string) string {
+		for i := 0; i < len(items); i++ {
+			parent, ok := parents[id]
+			if !ok {
+				break
+			}
+			id = parent
+		}
+		return id
+	}
+
+	var file model.ImportFile
+	rows := make(map[string]int, len(items))
+	for i, item := range items {
+		if item.IsDeleted {
+			file.Skipped = append(file.Skipped, model.ImportRowError{Row: i + 1, Error: "deleted task"})
+			continue
+		}
+		if item.ParentID != nil {
+			continue
+		}
+		if file.Full(limit) {
+			return model.ImportFile{}, model.ErrTooManyImportRows
+		}
+
+		todo := model.Todo{
+			Title:       item.Content,
+			Description: item.Description,
+			Status:      "Not Completed",
+			Priority:    todoistAPIPriorities[item.Priority],
+			Tags:        item.Labels,
+		}
+		if item.Checked || item.IsCompleted {
+			todo.Status = "Completed"
+			if item.CompletedAt != nil {
+				todo.CompletedAt, _ = parseTime(*item.CompletedAt, "")
+			}
+		}
+		if item.Due != nil {
+			date := item.Due.Datetime
+			if date == "" {
+				date = item.Due.Date
+			}
+			todoistDate(&todo, date, item.Due.Timezone)
+			if item.Due.IsRecurring {
+				todo.Recurrence = parseEvery(item.Due.String)
+				if todo.Recurrence == nil {
+					appendNote(&todo, "Todoist recurrence: "+item.Due.String)
+				}
+				finishRecurrence(&todo)
+			}
+		}
+
+		rows[item.ID] = len(file.Rows)
+		file.Rows = append(file.Rows, model.ImportRow{Row: i + 1, Todo: todo, Project: projects[item.ProjectID]})
+	}
+
+	for i, item := range items {
+		if item.ParentID == nil || item.IsDeleted {
+			continue
+		}
+		row, ok := rows[topLevel(item.ID)]
+		if !ok {
+			file.Skipped = append(file.Skipped, model.ImportRowError{Row: i + 1, Error: "subtask of a task that is not imported"})
+			continue
+		}
+		file.Rows[row].Checklist = append(file.Rows[row].Checklist, model.ImportChecklistItem{
+			Title: item.Content,
+			Done:  item.Checked || item.IsCompleted,
+		})
+	}
+	return file, nil
+}
@@ -0,0 +1,143 @@
+package importer
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// Trello reads the JSON export of a Trello board. The board becomes the
+// project of its cards, the list of a card and its labels become tags, and its
+// checklists become its checklist. Archived cards, and the cards of archived
+// lists, are skipped.
+type Trello struct{}
+
+func (Trello) Name() string {
+	return "trello"
+}
+
+type trelloBoard struct {
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID          string  `json:"id"`
+		Name        string  `json:"name"`
+		Desc        string  `json:"desc"`
+		IDList      string  `json:"idList"`
+		Closed      bool    `json:"closed"`
+		Due         *string `json:"due"`
+		DueComplete bool    `json:"dueComplete"`
+		Labels      []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Checklists []trelloChecklist `json:"checklists"`
+}
+
+type trelloChecklist struct {
+	IDCard     string            `json:"idCard"`
+	Pos        float64           `json:"pos"`
+	CheckItems []trelloCheckItem `json:"checkItems"`
+}
+
+type trelloCheckItem struct {
+	Name  string  `json:"name"`
+	State string  `json:"state"`
+	Pos   float64 `json:"pos"`
+}
+
+func (Trello) Read(r io.Reader, limit int) (model.ImportFile, error) {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return model.ImportFile{}, fmt.Errorf("invalid Trello JSON: %w", err)
+	}
+	if board.Cards == nil {
+		return model.ImportFile{}, errors.New("not a Trello board export, it has no cards")
+	}
+
+	lists := make(map[string]string, len(board.Lists))
+	closedLists := map[string]bool{}
+	for _, list := range board.Lists {
+		lists[list.ID] = list.Name
+		closedLists[list.ID] = list.Closed
+	}
+
+	var file model.ImportFile
+	rows := make(map[string]int, len(board.Cards))
+	for i, card := range board.Cards {
+		if card.Closed {
+			file.Skipped = append(file.Skipped, model.ImportRowError{Row: i + 1, Error: "archived card"})
+			continue
+		}
+		if closedLists[card.IDList] {
+			file.Skipped = append(file.Skipped, model.ImportRowError{Row: i + 1, Error: "card of an archived list"})
+			continue
+		}
+		if file.Full(limit) {
+			return model.ImportFile{}, model.ErrTooManyImportRows
+		}
+
+		todo := model.Todo{
+			Title:       card.Name,
+			Description: card.Desc,
+			Status:      "Not Completed",
+		}
+		if list := lists[card.IDList]; list != "" {
+			todo.Tags = append(todo.Tags, list)
+		}
+		for _, label := range card.Labels {
+			// labels may have only a color
+			if label.Name != "" {
+				todo.Tags = append(todo.Tags, label.Name)
+			} else if label.Color != "" {
+				todo.Tags = append(todo.Tags, label.Color)
+			}
+		}
+		if card.Due != nil {
+			deadline, err := parseTime(*card.Due, "")
+			if err != nil {
+				file.Errors = append(file.Errors, model.ImportRowError{Row: i + 1, Error: err.Error()})
+				continue
+			}
+			todo.Deadline = deadline
+		}
+		if card.DueComplete {
+			todo.Status = "Completed"
+		}
+
+		rows[card.ID] = len(file.Rows)
+		file.Rows = append(file.Rows, model.ImportRow{Row: i + 1, Todo: todo, Project: board.Name})
+	}
+
+	checklists := board.Checklists
+	slices.SortStableFunc(checklists, func(a, b trelloChecklist) int {
+		return cmp.Compare(a.Pos, b.Pos)
+	})
+	for _, checklist := range checklists {
+		row, ok := rows[checklist.IDCard]
+		if !ok {
+			continue
+		}
+		items := checklist.CheckItems
+		slices.SortStableFunc(items, func(a, b trelloCheckItem) int {
+			return cmp.Compare(a.Pos, b.Pos)
+		})
+		for _, item := range items {
+			file.Rows[row].Checklist = append(file.Rows[row].Checklist, model.ImportChecklistItem{
+				Title: item.Name,
+				Done:  item.State == "complete",
+			})
+		}
+	}
+	return file, nil
+}
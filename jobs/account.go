@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+)
+
+// PurgeDeletedAccounts returns a job that erases the users deleted longer
+// than grace ago, with everything they owned, freeing their emails.
+func PurgeDeletedAccounts(grace time.Duration) func() error {
+	return func() error {
+		purged, err := dbhelper.PurgeDeletedUsers(time.Now().Add(-grace))
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("purged %d deleted accounts", purged)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+package jobs
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/notify"
+)
+
+const (
+	// maxAccountExportsPerRun bounds the work of one run; the rest waits for the next.
+	maxAccountExportsPerRun = 10
+	// accountExportLease is how long a claimed export is left to its replica; it
+	// must outlast building one.
+	accountExportLease = 10 * time.Minute
+)
+
+// BuildAccountExports returns a job that builds the ZIP of every requested
+// account export and deletes the exports older than retention. Each export is
+// claimed with a lease, so replicas never build the same one, and built with no
+// transaction open. An export that cannot be built is marked failed and may be
+// requested again.
+func BuildAccountExports(retention time.Duration) func() error {
+	return func() error {
+		purged, err := dbhelper.PurgeExpiredAccountExports()
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("purged %d expired account exports", purged)
+		}
+
+		for i := 0; i < maxAccountExportsPerRun; i++ {
+			export, err := dbhelper.ClaimAccountExport(accountExportLease)
+			if err != nil || export == nil {
+				return err
+			}
+
+			status := model.AccountExportReady
+			archive, err := buildAccountExport(export.UserID)
+			if err != nil {
+				log.Printf("account export %s failed: %v", export.ID, err)
+				status = model.AccountExportFailed
+			}
+			err = dbhelper.FinishAccountExport(export.ID, status, archive, time.Now().Add(retention))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// buildAccountExport writes everything held about a user into a ZIP with one
+// JSON file per kind of data.
+func buildAccountExport(userID string) ([]byte, error) {
+	profile, err := dbhelper.GetAccountProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := dbhelper.GetUserSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	todos, err := dbhelper.GetAccountTodos(userID)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := dbhelper.GetAccountProjects(userID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := dbhelper.GetTags(userID)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := dbhelper.GetSavedFilters(userID)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := dbhelper.GetWebhooks(userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := dbhelper.GetUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := dbhelper.GetUserHistory(userID)
+	if err != nil {
+		return nil, err
+	}
+	reminders, err := dbhelper.GetAccountReminders(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reminders {
+		reminders[i].Offset = notify.FormatOffset(reminders[i].OffsetMinutes)
+	}
+	deliveries, err := dbhelper.GetAccountWebhookDeliveries(userID)
+	if err != nil {
+		return nil, err
+	}
+	calendarTokens, err := dbhelper.GetAccountCalendarTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", profile},
+		{"settings.json", settings},
+		{"todos.json", todos},
+		{"projects.json", projects},
+		{"tags.json", tags},
+		{"saved_filters.json", filters},
+		{"webhooks.json", webhooks},
+		{"sessions.json", sessions},
+		{"history.json", history},
+		{"reminders.json", reminders},
+		{"webhook_deliveries.json", deliveries},
+		{"calendar_tokens.json", calendarTokens},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	modified := time.Now()
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: modified,
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
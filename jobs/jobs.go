@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// Run calls fn once every interval for as long as the process lives, logging
+// failures instead of stopping, so one bad run does not end the job.
+func Run(name string, interval time.Duration, fn func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := fn(); err != nil {
+			log.Printf("%s failed: %v", name, err)
+		}
+		<-ticker.C
+	}
+}
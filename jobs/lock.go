@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+// Postgres advisory lock keys of the jobs that must not run on two replicas at once.
+const (
+	lockKeyOverdue int64 = 74_000_001 + iota
+)
+
+// Locked returns a job running fn in a transaction that holds the advisory lock
+// key. When another replica holds the lock the run is skipped, so every run of
+// fn happens on a single replica.
+func Locked(key int64, fn func(tx *sqlx.Tx) error) func() error {
+	return func() error {
+		return database.Tx(func(tx *sqlx.Tx) error {
+			locked, err := dbhelper.TryAdvisoryLock(tx, key)
+			if err != nil || !locked {
+				return err
+			}
+			return fn(tx)
+		})
+	}
+}
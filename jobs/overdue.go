@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"log"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/jmoiron/sqlx"
+)
+
+// MarkOverdue returns a job that moves open todos past their deadline to the
+// Overdue status, and Overdue todos whose deadline was moved into the future
+// back to Pending.
+func MarkOverdue() func() error {
+	return Locked(lockKeyOverdue, func(tx *sqlx.Tx) error {
+		marked, err := dbhelper.MarkOverdueTodos(tx)
+		if err != nil {
+			return err
+		}
+		reopened, err := dbhelper.ReopenOverdueTodos(tx)
+		if err != nil {
+			return err
+		}
+		if marked > 0 || reopened > 0 {
+			log.Printf("marked %d todos overdue, reopened %d", marked, reopened)
+		}
+		return nil
+	})
+}
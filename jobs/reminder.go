@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/notify"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// maxReminderAttempts is how often a notifier tries a reminder before giving up.
+	maxReminderAttempts = 5
+	// maxRemindersPerRun bounds the work of one run; the rest waits for the next.
+	maxRemindersPerRun = 500
+	// reminderTimeout bounds the delivery of one reminder by one notifier.
+	reminderTimeout = 30 * time.Second
+)
+
+// SendReminders returns a job that delivers due reminders through every notifier.
+// Each reminder is claimed with a lease, so replicas never deliver the same
+// reminder, and delivered with no transaction open, which would hold back the
+// streams of todo events. It is marked done for the current deadline once every
+// notifier delivered it or gave up. Failed notifiers retry on the next run.
+func SendReminders(notifiers []notify.Notifier) func() error {
+	// the lease outlasts the delivery of a reminder by every notifier
+	lease := time.Duration(len(notifiers)+1) * reminderTimeout
+
+	return func() error {
+		started := time.Now()
+
+		for i := 0; i < maxRemindersPerRun; i++ {
+			reminder, err := dbhelper.ClaimDueReminder(started, lease)
+			if err != nil || reminder == nil {
+				return err
+			}
+			if err := deliverReminder(notifiers, *reminder); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// reminderAttempt is the outcome of delivering a reminder through one notifier.
+type reminderAttempt struct {
+	notifier string
+	errMsg   *string
+}
+
+func deliverReminder(notifiers []notify.Notifier, reminder model.DueReminder) error {
+	attempts, err := dbhelper.GetReminderAttempts(reminder.ReminderID, reminder.Deadline)
+	if err != nil {
+		return err
+	}
+	byNotifier := make(map[string]model.ReminderAttempts, len(attempts))
+	for _, attempt := range attempts {
+		byNotifier[attempt.Notifier] = attempt
+	}
+
+	done := true
+	var outcomes []reminderAttempt
+	for _, notifier := range notifiers {
+		attempt := byNotifier[notifier.Name()]
+		if attempt.Succeeded || attempt.Failures >= maxReminderAttempts {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), reminderTimeout)
+		err := notifier.Notify(ctx, reminder)
+		cancel()
+
+		var errMsg *string
+		if err != nil {
+			msg := err.Error()
+			errMsg = &msg
+			log.Printf("reminder %s via %s failed: %v", reminder.ReminderID, notifier.Name(), err)
+			done = done && attempt.Failures+1 >= maxReminderAttempts
+		}
+		outcomes = append(outcomes, reminderAttempt{notifier: notifier.Name(), errMsg: errMsg})
+	}
+
+	return database.Tx(func(tx *sqlx.Tx) error {
+		for _, outcome := range outcomes {
+			err := dbhelper.CreateReminderDelivery(tx, reminder.ReminderID, reminder.Deadline, outcome.notifier, outcome.errMsg)
+			if err != nil {
+				return err
+			}
+		}
+		if done {
+			if err := dbhelper.MarkReminderDone(tx, reminder.ReminderID, reminder.Deadline); err != nil {
+				return err
+			}
+		}
+		return dbhelper.ReleaseReminder(tx, reminder.ReminderID)
+	})
+}
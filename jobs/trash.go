@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+)
+
+// PurgeTrash returns a job that hard-deletes todos which have been in the trash
+// for longer than retention.
+func PurgeTrash(retention time.Duration) func() error {
+	return func() error {
+		purged, err := dbhelper.PurgeArchivedTodos(time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("purged %d todos from trash", purged)
+		}
+		return nil
+	}
+}
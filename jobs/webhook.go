@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/database/dbhelper"
+	"github.com/Shubhouy1/todo-app/model"
+	"github.com/Shubhouy1/todo-app/util"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// maxWebhookAttempts is how often a delivery is tried before it fails for good.
+	maxWebhookAttempts = 8
+	// webhookBackoff is the wait after the first failed attempt; it doubles with
+	// every further failure up to maxWebhookBackoff.
+	webhookBackoff    = 30 * time.Second
+	maxWebhookBackoff = 6 * time.Hour
+	// maxDeliveriesPerRun bounds the work of one run; the rest waits for the next.
+	maxDeliveriesPerRun = 500
+	dispatchBatchSize   = 1000
+	// webhookLease is how long a claimed delivery is left to its replica; it
+	// must outlast an attempt.
+	webhookLease = time.Minute
+)
+
+// webhookClient only connects to public addresses and does not follow
+// redirects, so webhooks cannot be pointed at services inside the network.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: util.PublicDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConnsPerHost: 2,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// DeliverWebhooks returns a job that fans new outbox events out to the webhooks
+// subscribed to them and then sends the deliveries that are due. Every delivery
+// is claimed with a lease, so replicas never send the same one, and sent with
+// no transaction open, which would hold back the streams of todo events.
+// Failed deliveries are retried with exponential backoff.
+func DeliverWebhooks() func() error {
+	return func() error {
+		for {
+			var dispatched int64
+			err := database.Tx(func(tx *sqlx.Tx) error {
+				var err error
+				dispatched, err = dbhelper.DispatchOutboxEvents(tx, dispatchBatchSize)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if dispatched < dispatchBatchSize {
+				break
+			}
+		}
+
+		for i := 0; i < maxDeliveriesPerRun; i++ {
+			delivery, err := dbhelper.ClaimWebhookDelivery(webhookLease)
+			if err != nil || delivery == nil {
+				return err
+			}
+			if err := sendWebhook(*delivery); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func sendWebhook(delivery model.PendingDelivery) error {
+	responseStatus, err := postWebhook(delivery)
+	if err == nil {
+		return dbhelper.UpdateWebhookDelivery(delivery.ID, model.DeliverySucceeded, responseStatus, nil, nil)
+	}
+
+	errMsg := err.Error()
+	attempts := delivery.Attempts + 1
+	if attempts >= maxWebhookAttempts {
+		log.Printf("webhook delivery %d failed for good: %v", delivery.ID, err)
+		return dbhelper.UpdateWebhookDelivery(delivery.ID, model.DeliveryFailed, responseStatus, &errMsg, nil)
+	}
+
+	backoff := webhookBackoff << (attempts - 1)
+	if backoff > maxWebhookBackoff {
+		backoff = maxWebhookBackoff
+	}
+	next := time.Now().Add(backoff)
+	return dbhelper.UpdateWebhookDelivery(delivery.ID, model.DeliveryPending, responseStatus, &errMsg, &next)
+}
+
+// postWebhook sends one signed delivery, returning the response status if
+// there was a response.
+func postWebhook(delivery model.PendingDelivery) (*int, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":         delivery.EventID,
+		"event":      delivery.Event,
+		"created_at": delivery.EventCreatedAt,
+		"data":       delivery.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookClient.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-app-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", util.SignWebhook(delivery.Secret, timestamp, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		return &status, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return &status, nil
+}
+
+// PurgeOutbox returns a job that deletes the todo events dispatched longer than
+// retention ago, with their delivery log. Streams resuming from before then
+// continue with the oldest event that is left.
+func PurgeOutbox(retention time.Duration) func() error {
+	return func() error {
+		purged, err := dbhelper.PurgeDispatchedOutboxEvents(time.Now().Add(-retention))
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			log.Printf("purged %d todo events from the outbox", purged)
+		}
+		return nil
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/Shubhouy1/todo-app/database"
+	"github.com/Shubhouy1/todo-app/jobs"
+	"github.com/Shubhouy1/todo-app/notify"
 	"github.com/Shubhouy1/todo-app/router"
+	"github.com/Shubhouy1/todo-app/stream"
 )
 
 func getEnv(key, fallback string) string {
@@ -26,6 +31,21 @@ func main() {
 	dbName := getEnv("DB_NAME", "mercury-dev")
 	sslMode := getEnv("DB_SSLMODE", string(database.SSLModeDisabled))
 	serverPort := getEnv("SERVER_PORT", "8080")
+	trashRetentionDays := getEnv("TRASH_RETENTION_DAYS", "30")
+	trashPurgeInterval := getEnv("TRASH_PURGE_INTERVAL", "1h")
+	accountGraceDays := getEnv("ACCOUNT_DELETION_GRACE_DAYS", "30")
+	overdueCheckInterval := getEnv("OVERDUE_CHECK_INTERVAL", "1m")
+	reminderCheckInterval := getEnv("REMINDER_INTERVAL", "1m")
+	// e.g. localhost:1025 for a local mail catcher
+	smtpAddr := getEnv("SMTP_ADDR", "")
+	smtpFrom := getEnv("SMTP_FROM", "todo-app@localhost")
+	smtpUsername := getEnv("SMTP_USERNAME", "")
+	smtpPassword := getEnv("SMTP_PASSWORD", "")
+	reminderWebhookURL := getEnv("REMINDER_WEBHOOK_URL", "")
+	webhookDeliveryInterval := getEnv("WEBHOOK_INTERVAL", "10s")
+	outboxRetentionDays := getEnv("OUTBOX_RETENTION_DAYS", "7")
+	accountExportInterval := getEnv("ACCOUNT_EXPORT_INTERVAL", "30s")
+	accountExportRetentionDays := getEnv("ACCOUNT_EXPORT_RETENTION_DAYS", "7")
 
 	err := database.CreateAndMigrate(
 		dbHost,
@@ -39,6 +59,76 @@ func main() {
 		panic(err)
 	}
 
+	retentionDays, err := strconv.Atoi(trashRetentionDays)
+	if err != nil || retentionDays <= 0 {
+		panic(fmt.Sprintf("invalid TRASH_RETENTION_DAYS %q", trashRetentionDays))
+	}
+	purgeInterval, err := time.ParseDuration(trashPurgeInterval)
+	if err != nil || purgeInterval <= 0 {
+		panic(fmt.Sprintf("invalid TRASH_PURGE_INTERVAL %q", trashPurgeInterval))
+	}
+	go jobs.Run("trash purge", purgeInterval, jobs.PurgeTrash(time.Duration(retentionDays)*24*time.Hour))
+
+	graceDays, err := strconv.Atoi(accountGraceDays)
+	if err != nil || graceDays <= 0 {
+		panic(fmt.Sprintf("invalid ACCOUNT_DELETION_GRACE_DAYS %q", accountGraceDays))
+	}
+	go jobs.Run("account purge", purgeInterval, jobs.PurgeDeletedAccounts(time.Duration(graceDays)*24*time.Hour))
+
+	overdueInterval, err := time.ParseDuration(overdueCheckInterval)
+	if err != nil || overdueInterval <= 0 {
+		panic(fmt.Sprintf("invalid OVERDUE_CHECK_INTERVAL %q", overdueCheckInterval))
+	}
+	go jobs.Run("overdue check", overdueInterval, jobs.MarkOverdue())
+
+	var notifiers []notify.Notifier
+	if smtpAddr != "" {
+		notifiers = append(notifiers, notify.SMTP{
+			Addr:     smtpAddr,
+			From:     smtpFrom,
+			Username: smtpUsername,
+			Password: smtpPassword,
+		})
+	}
+	if reminderWebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhook(reminderWebhookURL))
+	}
+	reminderInterval, err := time.ParseDuration(reminderCheckInterval)
+	if err != nil || reminderInterval <= 0 {
+		panic(fmt.Sprintf("invalid REMINDER_INTERVAL %q", reminderCheckInterval))
+	}
+	if len(notifiers) > 0 {
+		go jobs.Run("reminders", reminderInterval, jobs.SendReminders(notifiers))
+	} else {
+		fmt.Println("No reminder notifier configured, reminders are not sent")
+	}
+
+	webhookInterval, err := time.ParseDuration(webhookDeliveryInterval)
+	if err != nil || webhookInterval <= 0 {
+		panic(fmt.Sprintf("invalid WEBHOOK_INTERVAL %q", webhookDeliveryInterval))
+	}
+	go jobs.Run("webhook delivery", webhookInterval, jobs.DeliverWebhooks())
+
+	outboxRetention, err := strconv.Atoi(outboxRetentionDays)
+	if err != nil || outboxRetention <= 0 {
+		panic(fmt.Sprintf("invalid OUTBOX_RETENTION_DAYS %q", outboxRetentionDays))
+	}
+	go jobs.Run("outbox purge", purgeInterval, jobs.PurgeOutbox(time.Duration(outboxRetention)*24*time.Hour))
+
+	exportInterval, err := time.ParseDuration(accountExportInterval)
+	if err != nil || exportInterval <= 0 {
+		panic(fmt.Sprintf("invalid ACCOUNT_EXPORT_INTERVAL %q", accountExportInterval))
+	}
+	exportRetentionDays, err := strconv.Atoi(accountExportRetentionDays)
+	if err != nil || exportRetentionDays <= 0 {
+		panic(fmt.Sprintf("invalid ACCOUNT_EXPORT_RETENTION_DAYS %q", accountExportRetentionDays))
+	}
+	go jobs.Run("account export", exportInterval, jobs.BuildAccountExports(time.Duration(exportRetentionDays)*24*time.Hour))
+
+	if err := stream.Start(); err != nil {
+		panic(err)
+	}
+
 	fmt.Println("Server running on port", serverPort)
 
 	if err := http.ListenAndServe(":"+serverPort, r); err != nil {
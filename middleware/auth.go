@@ -7,15 +7,19 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Shubhouy1/todo-app/database/dbhelper"
 	"github.com/Shubhouy1/todo-app/util"
 	"github.com/form3tech-oss/jwt-go"
 )
 
+// AuthContext is who a request is made by. ExpiresAt is when the token it
+// carries expires, which long-lived connections check on their own.
 type AuthContext struct {
 	UserID    string
 	SessionID int64
+	ExpiresAt time.Time
 }
 
 type contextKey string
@@ -87,9 +91,13 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// jwt-go has already checked that exp is in the future
+		exp, _ := claims["exp"].(float64)
+
 		authCtx := AuthContext{
 			UserID:    userID,
 			SessionID: sessionID,
+			ExpiresAt: time.Unix(int64(exp), 0),
 		}
 
 		ctx := context.WithValue(r.Context(), authKey, authCtx)
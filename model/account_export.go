@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+const (
+	AccountExportPending = "pending"
+	AccountExportReady   = "ready"
+	AccountExportFailed  = "failed"
+)
+
+// AccountExport is a ZIP of everything held about a user, built in the
+// background after POST /me/export and downloadable until ExpiresAt.
+type AccountExport struct {
+	ID         string     `json:"id" db:"id"`
+	Status     string     `json:"status" db:"status"`
+	Size       *int64     `json:"size" db:"size"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	FinishedAt *time.Time `json:"finished_at" db:"finished_at"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// PendingAccountExport is an export claimed by the job that builds it.
+type PendingAccountExport struct {
+	ID     string `db:"id"`
+	UserID string `db:"user_id"`
+}
+
+// AccountProfile is the profile of a user as an account export holds it.
+type AccountProfile struct {
+	ID        string     `json:"id" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Email     string     `json:"email" db:"email"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type UserSession struct {
+	SessionID  int64      `json:"session_id" db:"session_id"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	ArchivedAt *time.Time `json:"archived_at" db:"archived_at"`
+}
+
+// AccountReminder is a reminder as an account export holds it, with its todo.
+type AccountReminder struct {
+	TodoID string `json:"todo_id" db:"todo_id"`
+	Reminder
+}
+
+// AccountWebhookDelivery is a webhook delivery as an account export holds it,
+// with its webhook.
+type AccountWebhookDelivery struct {
+	WebhookID string `json:"webhook_id" db:"webhook_id"`
+	WebhookDelivery
+}
+
+// AccountCalendarToken is a calendar token as an account export holds it. The
+// secret itself is only ever stored as a hash and is not part of the export.
+type AccountCalendarToken struct {
+	CalendarToken
+	RevokedAt *time.Time `json:"revoked_at" db:"revoked_at"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// CalendarToken is the token in use for the calendar feed of a user. The
+// token itself is only shown when it is created.
+type CalendarToken struct {
+	ID         string     `json:"id" db:"id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+const (
+	HistoryCreated       = "created"
+	HistoryUpdated       = "updated"
+	HistoryStatusChanged = "status_changed"
+	HistoryDeleted       = "deleted"
+	HistoryRestored      = "restored"
+)
+
+// FieldChange is the value of one todo field before and after a change.
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// TodoHistory is one entry of a todo's audit trail. Changes maps field names
+// to their FieldChange, and SessionID is empty for changes made by the server itself.
+type TodoHistory struct {
+	ID        int64          `json:"id" db:"id"`
+	TodoID    string         `json:"todo_id" db:"todo_id"`
+	SessionID *int64         `json:"session_id" db:"session_id"`
+	Action    string         `json:"action" db:"action"`
+	Changes   types.JSONText `json:"changes" db:"changes"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+type Project struct {
+	ID          string     `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Description string     `json:"description" db:"description"`
+	TodoCount   int        `json:"todo_count" db:"todo_count"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ArchivedAt  *time.Time `json:"archived_at" db:"archived_at"`
+}
+
+type ProjectRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=500"`
+}
+
+// ProjectOrderRequest lists the todos of a project in their new order.
+type ProjectOrderRequest struct {
+	TodoIDs []string `json:"todo_ids" validate:"required,min=1,dive,uuid"`
+}
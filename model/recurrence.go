@@ -0,0 +1,39 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+// Recurrence is an RRULE-style schedule. Interval counts days, weeks or months
+// depending on Frequency, so "every N days" is a daily rule with Interval N.
+type Recurrence struct {
+	Frequency string     `json:"frequency" validate:"required,oneof=daily weekly monthly"`
+	Interval  int        `json:"interval,omitempty" validate:"omitempty,min=1,max=365"`
+	Weekdays  []string   `json:"weekdays,omitempty" validate:"omitempty,dive,oneof=MO TU WE TH FR SA SU"`
+	MonthDay  int        `json:"month_day,omitempty" validate:"required_if=Frequency monthly,omitempty,min=1,max=31"`
+	Until     *time.Time `json:"until,omitempty"`
+}
+
+func (r Recurrence) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+func (r *Recurrence) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	default:
+		return fmt.Errorf("cannot scan %T into Recurrence", src)
+	}
+}
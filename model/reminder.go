@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Reminder notifies the owner of a todo OffsetMinutes before its deadline.
+// Done tells whether it has been handled for the current deadline.
+type Reminder struct {
+	ID            string     `json:"id" db:"id"`
+	Offset        string     `json:"offset" db:"-"`
+	OffsetMinutes int        `json:"offset_minutes" db:"offset_minutes"`
+	RemindAt      *time.Time `json:"remind_at" db:"remind_at"`
+	Done          bool       `json:"done" db:"done"`
+	DoneAt        *time.Time `json:"done_at" db:"done_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ReminderRequest replaces the reminders of a todo. Offsets are durations
+// before the deadline such as "30m", "1h", "1d" or "1w".
+type ReminderRequest struct {
+	Offsets []string `json:"offsets" validate:"max=10,dive,required"`
+}
+
+// DueReminder is a reminder whose time has come, with what a notifier needs
+// to tell the user about it.
+type DueReminder struct {
+	ReminderID    string    `json:"reminder_id" db:"reminder_id"`
+	TodoID        string    `json:"todo_id" db:"todo_id"`
+	Title         string    `json:"title" db:"title"`
+	Deadline      time.Time `json:"deadline" db:"deadline"`
+	OffsetMinutes int       `json:"offset_minutes" db:"offset_minutes"`
+	UserID        string    `json:"user_id" db:"user_id"`
+	Name          string    `json:"name" db:"name"`
+	Email         string    `json:"email" db:"email"`
+}
+
+// ReminderDelivery is one attempt of a notifier to deliver a reminder.
+type ReminderDelivery struct {
+	ID            int64     `json:"id" db:"id"`
+	ReminderID    string    `json:"reminder_id" db:"reminder_id"`
+	OffsetMinutes int       `json:"offset_minutes" db:"offset_minutes"`
+	Deadline      time.Time `json:"deadline" db:"deadline"`
+	Notifier      string    `json:"notifier" db:"notifier"`
+	Success       bool      `json:"success" db:"success"`
+	Error         *string   `json:"error" db:"error"`
+	AttemptedAt   time.Time `json:"attempted_at" db:"attempted_at"`
+}
+
+// ReminderAttempts sums up the deliveries of a reminder by one notifier for one deadline.
+type ReminderAttempts struct {
+	Notifier  string `db:"notifier"`
+	Succeeded bool   `db:"succeeded"`
+	Failures  int    `db:"failures"`
+}
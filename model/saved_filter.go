@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// SavedFilter is a named view of GET /todos. Filter is a query string with the
+// filter parameters of GET /todos, such as "q=is:overdue&tag=work".
+type SavedFilter struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Filter    string    `json:"filter" db:"filter"`
+	Sort      string    `json:"sort" db:"sort"`
+	PageSize  int       `json:"page_size" db:"page_size"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type SavedFilterRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Filter   string `json:"filter" validate:"max=2000"`
+	Sort     string `json:"sort" validate:"max=200"`
+	PageSize int    `json:"page_size" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// Late completion policies: what happens when a todo is completed after its deadline.
+const (
+	LateCompletionForbid = "forbid"
+	LateCompletionAllow  = "allow"
+	LateCompletionFlag   = "allow_and_flag"
+)
+
+// UserSettings are the per-user preferences of the todo app.
+type UserSettings struct {
+	LateCompletion string `json:"late_completion" db:"late_completion"`
+}
+
+// UserSettingsPatch carries the settings to change; nil fields are kept.
+type UserSettingsPatch struct {
+	LateCompletion *string `json:"late_completion" validate:"omitempty,oneof=forbid allow allow_and_flag"`
+}
@@ -0,0 +1,51 @@
+package model
+
+import "encoding/json"
+
+// Commands a client can send over GET /ws.
+const (
+	SocketCreate = "create"
+	SocketUpdate = "update"
+	SocketStatus = "status"
+	SocketPing   = "ping"
+)
+
+// Messages the server sends over GET /ws.
+const (
+	SocketAck   = "ack"
+	SocketError = "error"
+	SocketEvent = "event"
+)
+
+// SocketRequest is a command sent by a client over GET /ws. ID is chosen by the
+// client and repeated in the reply. Version, when set, has to match the version
+// of the todo like an If-Match header does.
+type SocketRequest struct {
+	ID      string          `json:"id" validate:"required,max=100"`
+	Type    string          `json:"type" validate:"required,oneof=create update status ping"`
+	TodoID  string          `json:"todo_id" validate:"required_if=Type update,required_if=Type status,omitempty,uuid"`
+	Version *int            `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// SocketStatusData is the data of a status command.
+type SocketStatusData struct {
+	Status string `json:"status" validate:"required,oneof=Completed 'Not Completed' Pending"`
+}
+
+// SocketMessage is a message sent by the server over GET /ws: the ack or error
+// replying to the request with the same ID, or an event without one.
+type SocketMessage struct {
+	ID    string         `json:"id,omitempty"`
+	Type  string         `json:"type"`
+	Data  interface{}    `json:"data,omitempty"`
+	Error *SocketFailure `json:"error,omitempty"`
+}
+
+// SocketFailure tells why a request failed, with the status the same request
+// would get over HTTP.
+type SocketFailure struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Detail  string `json:"detail,omitempty"`
+}
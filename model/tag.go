@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type Tag struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	TodoCount int       `json:"todo_count" db:"todo_count"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type TagRequest struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+type MergeTagRequest struct {
+	TargetID string `json:"target_id" validate:"required,uuid"`
+}
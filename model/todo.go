@@ -0,0 +1,128 @@
+package model
+
+import "time"
+
+const (
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+// TodoFilter holds the optional filters accepted by GET /todos.
+type TodoFilter struct {
+	Status    string
+	Deadline  *time.Time
+	Tags      []string
+	TagMode   string
+	ProjectID *string
+	Query     []TodoQueryTerm
+}
+
+// TodoQueryTerm is one term of the ?q= filter language of GET /todos, such as
+// status:Pending,Completed, due<2026-11-01 or -tag:personal. Op is one of
+// ":", "<", "<=", ">" and ">=". Negate excludes the todos the term matches.
+type TodoQueryTerm struct {
+	Field  string
+	Op     string
+	Values []string
+	Negate bool
+}
+
+// TodoPatch holds the fields PATCH /todos/{id} can change. The request body is
+// a JSON merge patch (RFC 7396) applied onto these fields of the stored todo.
+type TodoPatch struct {
+	Title        string      `json:"title" validate:"required"`
+	Description  string      `json:"description,omitempty"`
+	Deadline     *time.Time  `json:"deadline,omitempty"`
+	Status       string      `json:"status" validate:"required,oneof=Completed 'Not Completed' Pending Overdue"`
+	Priority     string      `json:"priority" validate:"required,oneof=P0 P1 P2 P3"`
+	ProjectID    *string     `json:"project_id,omitempty" validate:"omitempty,uuid"`
+	Tags         []string    `json:"tags,omitempty" validate:"dive,max=50"`
+	AutoComplete bool        `json:"auto_complete"`
+	Recurrence   *Recurrence `json:"recurrence,omitempty"`
+}
+
+const (
+	BulkSetStatus     = "set_status"
+	BulkMoveToProject = "move_to_project"
+	BulkAddTag        = "add_tag"
+	BulkRemoveTag     = "remove_tag"
+	BulkShiftDeadline = "shift_deadline"
+	BulkArchive       = "archive"
+	BulkRestore       = "restore"
+)
+
+// BulkRequest is the body of POST /todos/bulk. The todos are picked by IDs or
+// by Filter, a query string with the parameters of GET /todos such as
+// "status=Pending&tag=ops". Atomic rolls every change back when one item fails.
+type BulkRequest struct {
+	IDs       []string `json:"ids" validate:"omitempty,max=500,dive,uuid"`
+	Filter    string   `json:"filter"`
+	Action    string   `json:"action" validate:"required,oneof=set_status move_to_project add_tag remove_tag shift_deadline archive restore"`
+	Status    string   `json:"status" validate:"required_if=Action set_status,omitempty,oneof=Completed 'Not Completed' Pending"`
+	ProjectID *string  `json:"project_id" validate:"omitempty,uuid"`
+	Tag       string   `json:"tag" validate:"required_if=Action add_tag,required_if=Action remove_tag,max=50"`
+	ShiftDays int      `json:"shift_days" validate:"required_if=Action shift_deadline"`
+	Atomic    bool     `json:"atomic"`
+}
+
+// BulkResult reports what a bulk operation did to one todo.
+type BulkResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SortField is one key of the ?sort= parameter of GET /todos.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// TodoCursor marks a position in a sorted list of todos: the sort key values
+// of one todo and the sort they belong to. Before reads the page preceding
+// that todo instead of the one following it.
+type TodoCursor struct {
+	Sort   string    `json:"s"`
+	Keys   []*string `json:"k"`
+	Before bool      `json:"b,omitempty"`
+}
+
+// TodoPage is one page of todos with the cursors of its neighbouring pages,
+// nil where there is no such page.
+type TodoPage struct {
+	Todos []Todo
+	Next  *TodoCursor
+	Prev  *TodoCursor
+}
+
+// TodoSearchResult is a todo matched by GET /todos/search with its rank and
+// highlighted snippets.
+type TodoSearchResult struct {
+	Todo
+	Rank               float64 `json:"rank" db:"rank"`
+	TitleSnippet       string  `json:"title_snippet" db:"title_snippet"`
+	DescriptionSnippet string  `json:"description_snippet" db:"description_snippet"`
+}
+
+type TodoItem struct {
+	ID        string    `json:"id" db:"id"`
+	Title     string    `json:"title" db:"title"`
+	Done      bool      `json:"done" db:"done"`
+	Position  int       `json:"position" db:"position"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type TodoItemRequest struct {
+	Title string `json:"title" validate:"required,max=200"`
+}
+
+// TodoItemPatch carries the checklist item fields to change; nil fields are kept.
+type TodoItemPatch struct {
+	Title *string `json:"title" validate:"omitempty,min=1,max=200"`
+	Done  *bool   `json:"done"`
+}
+
+// TodoItemOrderRequest lists the checklist items of a todo in their new order.
+type TodoItemOrderRequest struct {
+	ItemIDs []string `json:"item_ids" validate:"required,min=1,dive,uuid"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import "errors"
+
+// Formats todos can be exported and imported in.
+const (
+	FormatCSV     = "csv"
+	FormatJSON    = "json"
+	FormatTodoTxt = "todotxt"
+)
+
+// ImportRowError tells why a row of an import cannot be imported, or why it was
+// left out. Row counts lines for CSV and todo.txt, header included, and tasks
+// in file order for JSON.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportRow is a row of an import read into a todo. Project names the project
+// of the todo in the tool it comes from; it is matched by name or created.
+// Checklist becomes the checklist of the todo.
+type ImportRow struct {
+	Row       int
+	Todo      Todo
+	Project   string
+	Checklist []ImportChecklistItem
+}
+
+type ImportChecklistItem struct {
+	Title string
+	Done  bool
+}
+
+// ImportFile is what an import read from a file. Errors are rows that cannot
+// be imported, Skipped the ones left out on purpose, like archived cards.
+type ImportFile struct {
+	Rows    []ImportRow
+	Errors  []ImportRowError
+	Skipped []ImportRowError
+}
+
+// ErrTooManyImportRows is returned by the readers of an import as soon as a
+// file turns out to have more rows than they were allowed to read.
+var ErrTooManyImportRows = errors.New("too many rows to import")
+
+// Full reports whether the file holds limit rows, those that cannot be
+// imported included, so that another one would be too many.
+func (f ImportFile) Full(limit int) bool {
+	return len(f.Rows)+len(f.Errors) >= limit
+}
+
+// ImportReport is the outcome of POST /todos/import, or its preview for a dry
+// run. Nothing is imported while Errors is not empty.
+type ImportReport struct {
+	Format          string           `json:"format"`
+	DryRun          bool             `json:"dry_run"`
+	Total           int              `json:"total"`
+	Valid           int              `json:"valid"`
+	Imported        int              `json:"imported"`
+	Completed       int              `json:"completed"`
+	ChecklistItems  int              `json:"checklist_items"`
+	Tags            []string         `json:"tags"`
+	ProjectsMatched []string         `json:"projects_matched"`
+	ProjectsCreated []string         `json:"projects_created"`
+	Errors          []ImportRowError `json:"errors"`
+	Skipped         []ImportRowError `json:"skipped"`
+	Preview         []Todo           `json:"preview,omitempty"`
+	IDs             []string         `json:"ids,omitempty"`
+}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type UserRequest struct {
 	Username string `json:"username" db:"username" validate:"required,min=3"`
@@ -17,21 +21,38 @@ type User struct {
 	ArchivedAt *time.Time `json:"archived_at" db:"archived_at"`
 }
 
+// LoginRequest logs a user in. Reactivate restores an account that was
+// deleted but not purged yet; without it, logging in to one is refused.
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	Reactivate bool   `json:"reactivate"`
 }
 
 type Todo struct {
-	ID          string    `json:"id" db:"id"`
-	Title       string    `json:"title" db:"title"`
-	Status      string    `json:"status" db:"status"`
-	Description string    `json:"description" db:"description"`
-	Deadline    time.Time `json:"deadline" db:"deadline"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	ID            string         `json:"id" db:"id"`
+	Title         string         `json:"title" db:"title"`
+	Status        string         `json:"status" db:"status" validate:"omitempty,oneof=Completed 'Not Completed' Pending Overdue"`
+	Description   string         `json:"description" db:"description"`
+	Deadline      *time.Time     `json:"deadline" db:"deadline"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+	Tags          pq.StringArray `json:"tags" db:"tags" validate:"dive,max=50"`
+	ProjectID     *string        `json:"project_id" db:"project_id"`
+	Position      int            `json:"position" db:"position"`
+	AutoComplete  bool           `json:"auto_complete" db:"auto_complete"`
+	Progress      string         `json:"progress,omitempty" db:"progress"`
+	Items         []TodoItem     `json:"items,omitempty" db:"-"`
+	Recurrence    *Recurrence    `json:"recurrence" db:"recurrence"`
+	SeriesID      *string        `json:"series_id,omitempty" db:"series_id"`
+	Priority      string         `json:"priority" db:"priority" validate:"omitempty,oneof=P0 P1 P2 P3"`
+	ArchivedAt    *time.Time     `json:"archived_at,omitempty" db:"archived_at"`
+	Version       int            `json:"version" db:"version"`
+	CompletedAt   *time.Time     `json:"completed_at" db:"completed_at"`
+	CompletedLate bool           `json:"completed_late" db:"completed_late"`
 }
 
 type UserExist struct {
-	ID       string `db:"id"`
-	Password string `db:"password"`
+	ID         string     `db:"id"`
+	Password   string     `db:"password"`
+	ArchivedAt *time.Time `db:"archived_at"`
 }
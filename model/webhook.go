@@ -0,0 +1,120 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+)
+
+// Todo lifecycle events webhooks can subscribe to, one for each history action.
+const (
+	EventTodoCreated       = "todo.created"
+	EventTodoUpdated       = "todo.updated"
+	EventTodoStatusChanged = "todo.status_changed"
+	EventTodoDeleted       = "todo.deleted"
+	EventTodoRestored      = "todo.restored"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a user's subscription to todo events. The secret signing its
+// deliveries is only shown when the webhook is created.
+type Webhook struct {
+	ID        string         `json:"id" db:"id"`
+	URL       string         `json:"url" db:"url"`
+	Secret    string         `json:"-" db:"secret"`
+	Events    pq.StringArray `json:"events" db:"events"`
+	Active    bool           `json:"active" db:"active"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,startswith=http,max=2000"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=todo.created todo.updated todo.status_changed todo.deleted todo.restored"`
+	Active *bool    `json:"active"`
+}
+
+// TodoEvent is the payload of an outbox event: the todo after the change, or
+// before it for deletions, and the fields that changed.
+type TodoEvent struct {
+	Todo    *Todo                  `json:"todo"`
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+// WebhookDelivery is the delivery of one event to one webhook with the outcome
+// of its latest attempt.
+type WebhookDelivery struct {
+	ID             int64      `json:"id" db:"id"`
+	EventID        int64      `json:"event_id" db:"event_id"`
+	Event          string     `json:"event" db:"event"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	ResponseStatus *int       `json:"response_status" db:"response_status"`
+	Error          *string    `json:"error" db:"error"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at" db:"delivered_at"`
+}
+
+// PendingDelivery is a webhook delivery due for an attempt, with everything
+// needed to send it.
+type PendingDelivery struct {
+	ID             int64          `db:"id"`
+	Attempts       int            `db:"attempts"`
+	URL            string         `db:"url"`
+	Secret         string         `db:"secret"`
+	EventID        int64          `db:"event_id"`
+	Event          string         `db:"event"`
+	Payload        types.JSONText `db:"payload"`
+	EventCreatedAt time.Time      `db:"event_created_at"`
+}
+
+// OutboxEvent is a stored todo event as streamed to clients of GET /todos/stream.
+// XID is the transaction that wrote it, and Cursor the position in the stream
+// after the event, which a client resumes from.
+type OutboxEvent struct {
+	ID        int64          `json:"id" db:"id"`
+	XID       int64          `json:"-" db:"xid"`
+	Cursor    string         `json:"cursor" db:"-"`
+	TodoID    string         `json:"todo_id" db:"todo_id"`
+	Event     string         `json:"event" db:"event"`
+	Payload   types.JSONText `json:"payload" db:"payload"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// TodoEventCursor is the position of a stream of todo events, which are read
+// in the order of the transactions that wrote them and then by ID. It is sent
+// to clients as "<xid>-<id>".
+type TodoEventCursor struct {
+	XID int64
+	ID  int64
+}
+
+func (c TodoEventCursor) String() string {
+	return strconv.FormatInt(c.XID, 10) + "-" + strconv.FormatInt(c.ID, 10)
+}
+
+// ParseTodoEventCursor reads a cursor written by TodoEventCursor.String.
+func ParseTodoEventCursor(raw string) (TodoEventCursor, error) {
+	xid, id, ok := strings.Cut(raw, "-")
+	if !ok {
+		return TodoEventCursor{}, errors.New("event id must be <xid>-<id>")
+	}
+	var cursor TodoEventCursor
+	var err error
+	if cursor.XID, err = strconv.ParseInt(xid, 10, 64); err != nil || cursor.XID < 0 {
+		return TodoEventCursor{}, errors.New("invalid event id")
+	}
+	if cursor.ID, err = strconv.ParseInt(id, 10, 64); err != nil || cursor.ID < 0 {
+		return TodoEventCursor{}, errors.New("invalid event id")
+	}
+	return cursor, nil
+}
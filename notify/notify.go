@@ -0,0 +1,53 @@
+// Package notify delivers deadline reminders to users.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// Notifier delivers a reminder through one channel, such as email.
+type Notifier interface {
+	// Name identifies the notifier in the delivery log.
+	Name() string
+	Notify(ctx context.Context, reminder model.DueReminder) error
+}
+
+// reminderText is the human readable message of a reminder.
+func reminderText(reminder model.DueReminder) string {
+	return fmt.Sprintf(
+		"Hi %s,\n\nyour todo %q is due %s (%s).\n",
+		reminder.Name,
+		reminder.Title,
+		FormatOffset(reminder.OffsetMinutes),
+		reminder.Deadline.Format(time.RFC1123),
+	)
+}
+
+// FormatOffset describes an offset before a deadline, e.g. "in 1 day" or "in 90 minutes".
+func FormatOffset(minutes int) string {
+	units := []struct {
+		minutes int
+		name    string
+	}{
+		{7 * 24 * 60, "week"},
+		{24 * 60, "day"},
+		{60, "hour"},
+		{1, "minute"},
+	}
+
+	for _, unit := range units {
+		if minutes%unit.minutes != 0 {
+			continue
+		}
+		n := minutes / unit.minutes
+		if n == 1 {
+			return "in 1 " + unit.name
+		}
+		return fmt.Sprintf("in %d %ss", n, unit.name)
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// SMTP emails reminders to the owner of the todo. Without Username the server
+// is used unauthenticated, as local mail catchers like MailHog expect.
+type SMTP struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+func (s SMTP) Name() string {
+	return "smtp"
+}
+
+// Notify sends the reminder as one email. Unlike smtp.SendMail it honours ctx,
+// dialling with it and bounding the whole conversation by its deadline.
+func (s SMTP) Notify(ctx context.Context, reminder model.DueReminder) error {
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{
+		"From: " + s.From,
+		"To: " + reminder.Email,
+		"Subject: " + mimeHeader(fmt.Sprintf("Reminder: %s", reminder.Title)),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	message := strings.Join(headers, "\r\n") + "\r\n\r\n" +
+		strings.ReplaceAll(reminderText(reminder), "\n", "\r\n")
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(reminder.Email); err != nil {
+		return err
+	}
+	body, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := body.Write([]byte(message)); err != nil {
+		return err
+	}
+	if err := body.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// mimeHeader keeps user text from breaking out of a header line.
+func mimeHeader(value string) string {
+	value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+	return mime.QEncoding.Encode("utf-8", value)
+}
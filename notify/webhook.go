@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// Webhook posts reminders as JSON to a URL, expecting a 2xx response.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhook returns a Webhook notifier for url with a bounded request time.
+func NewWebhook(url string) Webhook {
+	return Webhook{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (wh Webhook) Name() string {
+	return "webhook"
+}
+
+func (wh Webhook) Notify(ctx context.Context, reminder model.DueReminder) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"event":    "todo.reminder",
+		"reminder": reminder,
+		"message":  reminderText(reminder),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wh.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
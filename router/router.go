@@ -10,16 +10,62 @@ func SetupRouter() chi.Router {
 	r := chi.NewRouter()
 	r.Post("/register", handler.RegisterUser)
 	r.Post("/login", handler.Login)
+	r.Get("/calendar/{token}.ics", handler.GetCalendarFeed)
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.AuthMiddleware)
 		r.Post("/logout", handler.Logout)
 		r.Post("/todo", handler.CreateTodo)
 		r.Get("/get-details", handler.GetUserDetail)
+		r.Post("/me/export", handler.RequestAccountExport)
+		r.Get("/me/export/{id}", handler.GetAccountExport)
+		r.Get("/ws", handler.ServeWebSocket)
+		r.Get("/calendar/token", handler.GetCalendarToken)
+		r.Post("/calendar/token", handler.RotateCalendarToken)
+		r.Delete("/calendar/token", handler.RevokeCalendarToken)
+		r.Get("/settings", handler.GetUserSettings)
+		r.Patch("/settings", handler.UpdateUserSettings)
 		r.Get("/todos", handler.GetTodos)
+		r.Get("/todos/search", handler.SearchTodos)
+		r.Get("/todos/trash", handler.GetTrash)
+		r.Get("/todos/stream", handler.StreamTodos)
+		r.Get("/todos/export", handler.ExportTodos)
+		r.Post("/todos/import", handler.ImportTodos)
+		r.Post("/todos/bulk", handler.BulkTodos)
 		r.Get("/todos/{id}", handler.GetTodoByID)
 		r.Put("/todos/{id}", handler.UpdateTodo)
 		r.Patch("/todos/{id}", handler.UpdateTodoStatus)
 		r.Delete("/todos/{id}", handler.DeleteTodo)
+		r.Get("/todos/{id}/series", handler.GetTodoSeries)
+		r.Post("/todos/{id}/restore", handler.RestoreTodo)
+		r.Get("/todos/{id}/history", handler.GetTodoHistory)
+		r.Get("/todos/{id}/reminders", handler.GetTodoReminders)
+		r.Put("/todos/{id}/reminders", handler.SetTodoReminders)
+		r.Get("/todos/{id}/reminders/deliveries", handler.GetReminderDeliveries)
+		r.Post("/todos/{id}/items", handler.CreateTodoItem)
+		r.Put("/todos/{id}/items/order", handler.ReorderTodoItems)
+		r.Patch("/todos/{id}/items/{itemID}", handler.UpdateTodoItem)
+		r.Delete("/todos/{id}/items/{itemID}", handler.DeleteTodoItem)
+		r.Get("/tags", handler.GetTags)
+		r.Put("/tags/{id}", handler.RenameTag)
+		r.Post("/tags/{id}/merge", handler.MergeTag)
+		r.Delete("/tags/{id}", handler.DeleteTag)
+		r.Post("/projects", handler.CreateProject)
+		r.Get("/projects", handler.GetProjects)
+		r.Get("/projects/{id}", handler.GetProjectByID)
+		r.Put("/projects/{id}", handler.UpdateProject)
+		r.Put("/projects/{id}/order", handler.ReorderProjectTodos)
+		r.Delete("/projects/{id}", handler.DeleteProject)
+		r.Post("/saved-filters", handler.CreateSavedFilter)
+		r.Get("/saved-filters", handler.GetSavedFilters)
+		r.Get("/saved-filters/{id}", handler.GetSavedFilterByID)
+		r.Put("/saved-filters/{id}", handler.UpdateSavedFilter)
+		r.Delete("/saved-filters/{id}", handler.DeleteSavedFilter)
+		r.Post("/webhooks", handler.CreateWebhook)
+		r.Get("/webhooks", handler.GetWebhooks)
+		r.Get("/webhooks/{id}", handler.GetWebhookByID)
+		r.Put("/webhooks/{id}", handler.UpdateWebhook)
+		r.Delete("/webhooks/{id}", handler.DeleteWebhook)
+		r.Get("/webhooks/{id}/deliveries", handler.GetWebhookDeliveries)
 		r.Delete("/delete-user", handler.DeleteUser)
 	})
 	return r
@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Shubhouy1/todo-app/database"
+	"github.com/lib/pq"
+)
+
+// channel is the NOTIFY channel the outbox_events trigger announces todo events on.
+const channel = "todo_events"
+
+// subscribers holds, per user, the wake up channels of the open streams on this
+// instance. The events themselves are read from outbox_events by each stream.
+var subscribers = struct {
+	sync.Mutex
+	users map[string]map[chan struct{}]bool
+}{users: map[string]map[chan struct{}]bool{}}
+
+// Start listens for todo events on a dedicated connection and wakes the streams
+// of the users they belong to. Every instance listens, so a change made through
+// any of them reaches the streams of all of them.
+func Start() error {
+	listener := database.NewListener(func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("todo event listener: %v", err)
+		}
+	})
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case notification := <-listener.Notify:
+				// nil follows a reconnect, after which notifications may have been missed
+				if notification == nil {
+					wakeAll()
+					continue
+				}
+
+				var event struct {
+					UserID string `json:"user_id"`
+				}
+				if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+					log.Printf("invalid todo event notification %q: %v", notification.Extra, err)
+					continue
+				}
+				wake(event.UserID)
+			case <-time.After(90 * time.Second):
+				// a ping notices a dead connection the listener would otherwise wait on
+				go func() {
+					if err := listener.Ping(); err != nil {
+						log.Printf("todo event listener ping failed: %v", err)
+					}
+				}()
+			}
+		}
+	}()
+	return nil
+}
+
+// Subscribe returns a channel that receives a value whenever new todo events of
+// the user may be stored, and a function to call once the stream ends. Wake ups
+// are coalesced, so a receiver has to read all events it has not seen yet.
+func Subscribe(userID string) (<-chan struct{}, func()) {
+	updates := make(chan struct{}, 1)
+
+	subscribers.Lock()
+	if subscribers.users[userID] == nil {
+		subscribers.users[userID] = map[chan struct{}]bool{}
+	}
+	subscribers.users[userID][updates] = true
+	subscribers.Unlock()
+
+	return updates, func() {
+		subscribers.Lock()
+		delete(subscribers.users[userID], updates)
+		if len(subscribers.users[userID]) == 0 {
+			delete(subscribers.users, userID)
+		}
+		subscribers.Unlock()
+	}
+}
+
+func wake(userID string) {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+
+	for updates := range subscribers.users[userID] {
+		notify(updates)
+	}
+}
+
+func wakeAll() {
+	subscribers.Lock()
+	defer subscribers.Unlock()
+
+	for _, users := range subscribers.users {
+		for updates := range users {
+			notify(updates)
+		}
+	}
+}
+
+func notify(updates chan struct{}) {
+	select {
+	case updates <- struct{}{}:
+	default:
+	}
+}
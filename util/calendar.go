@@ -0,0 +1,161 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+// Calendar components a todo can be exported as.
+const (
+	CalendarEvent = "VEVENT"
+	CalendarTodo  = "VTODO"
+)
+
+const icalTime = "20060102T150405Z"
+
+var icalStatuses = map[string]string{
+	"Completed":     "COMPLETED",
+	"Pending":       "IN-PROCESS",
+	"Not Completed": "NEEDS-ACTION",
+	"Overdue":       "NEEDS-ACTION",
+}
+
+// icalPriorities maps priorities onto the 1 (highest) to 9 (lowest) scale of iCalendar.
+var icalPriorities = map[string]string{
+	"P0": "1",
+	"P1": "3",
+	"P2": "5",
+	"P3": "7",
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+// Calendar renders todos with a deadline as an iCalendar (RFC 5545) document
+// with one component, VEVENT or VTODO, per todo. Events carry the status of
+// the todo in their description, as the status of an event cannot hold it.
+func Calendar(todos []model.Todo, component string, now time.Time) []byte {
+	var b strings.Builder
+	line := func(name, value string) {
+		writeICalLine(&b, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//todo-app//todos//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", "Todos")
+	line("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	line("X-PUBLISHED-TTL", "PT1H")
+
+	for _, todo := range todos {
+		if todo.Deadline == nil {
+			continue
+		}
+		deadline := todo.Deadline.UTC().Format(icalTime)
+
+		line("BEGIN", component)
+		line("UID", todo.ID+"@todo-app")
+		line("DTSTAMP", now.UTC().Format(icalTime))
+		line("CREATED", todo.CreatedAt.UTC().Format(icalTime))
+		line("SEQUENCE", strconv.Itoa(todo.Version))
+		line("SUMMARY", icalEscaper.Replace(todo.Title))
+
+		description := todo.Description
+		if component == CalendarEvent {
+			line("DTSTART", deadline)
+			line("TRANSP", "TRANSPARENT")
+			description = strings.TrimSpace("Status: " + todo.Status + "\n\n" + description)
+		} else {
+			// a repeating todo needs DTSTART for its RRULE to start from
+			if todo.Recurrence != nil {
+				line("DTSTART", deadline)
+			}
+			line("DUE", deadline)
+			line("STATUS", icalStatuses[todo.Status])
+			if todo.CompletedAt != nil {
+				line("COMPLETED", todo.CompletedAt.UTC().Format(icalTime))
+				line("PERCENT-COMPLETE", "100")
+			}
+		}
+		if description != "" {
+			line("DESCRIPTION", icalEscaper.Replace(description))
+		}
+		line("X-TODO-STATUS", icalEscaper.Replace(todo.Status))
+		if priority, ok := icalPriorities[todo.Priority]; ok {
+			line("PRIORITY", priority)
+		}
+		if len(todo.Tags) > 0 {
+			tags := make([]string, len(todo.Tags))
+			for i, tag := range todo.Tags {
+				tags[i] = icalEscaper.Replace(tag)
+			}
+			line("CATEGORIES", strings.Join(tags, ","))
+		}
+		// completing an occurrence creates the next one as a todo of its own, so
+		// only the open occurrence repeats, from its deadline on
+		if todo.Recurrence != nil && todo.Status != "Completed" {
+			line("RRULE", icalRecurrence(*todo.Recurrence))
+		}
+		line("END", component)
+	}
+
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// icalRecurrence writes rule as an RRULE value repeating the way NextOccurrence does.
+func icalRecurrence(rule model.Recurrence) string {
+	parts := []string{"FREQ=" + strings.ToUpper(rule.Frequency)}
+	if rule.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rule.Interval))
+	}
+
+	switch rule.Frequency {
+	case model.FrequencyWeekly:
+		// NextOccurrence counts weeks from Sunday
+		parts = append(parts, "WKST=SU")
+		if len(rule.Weekdays) > 0 {
+			parts = append(parts, "BYDAY="+strings.Join(rule.Weekdays, ","))
+		}
+	case model.FrequencyMonthly:
+		if rule.MonthDay <= 28 {
+			parts = append(parts, "BYMONTHDAY="+strconv.Itoa(rule.MonthDay))
+			break
+		}
+		// months too short for the day fall back to their last day
+		days := make([]string, 0, 4)
+		for day := 28; day <= rule.MonthDay; day++ {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","), "BYSETPOS=-1")
+	}
+
+	if rule.Until != nil {
+		parts = append(parts, "UNTIL="+rule.Until.UTC().Format(icalTime))
+	}
+	return strings.Join(parts, ";")
+}
+
+// writeICalLine ends a content line with CRLF, folding it after 75 octets
+// without splitting a UTF-8 character.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// the leading space of a continuation line counts towards its length
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
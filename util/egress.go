@@ -0,0 +1,82 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrNonPublicAddress is returned for requests to addresses inside private
+// networks or the host itself, which users must not be able to reach through
+// the server.
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// nonPublicPrefixes are the special-purpose ranges the netip predicates leave out.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// IsPublicAddr reports whether addr can be reached on the internet, as
+// opposed to loopback, private, link-local and other special-purpose ranges.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// PublicDialControl is a net.Dialer Control refusing connections to addresses
+// that are not public. It sees the address actually dialled, after the name has
+// been resolved, so a name that resolves to a private address only when it is
+// used is refused as well.
+func PublicDialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicAddr(addrPort.Addr()) {
+		return ErrNonPublicAddress
+	}
+	return nil
+}
+
+// CheckPublicURL resolves the host of a URL and makes sure each of its
+// addresses is public. It catches mistakes early; requests must still be made
+// through a dialer with PublicDialControl, as the name may resolve differently
+// later.
+func CheckPublicURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return ErrNonPublicAddress
+		}
+	}
+	return nil
+}
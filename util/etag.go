@@ -0,0 +1,23 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag formats a row version as a strong entity tag.
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// MatchETag reports whether an If-Match or If-None-Match header value lists etag.
+// The weak W/ prefix is ignored and "*" matches any entity tag.
+func MatchETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
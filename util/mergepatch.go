@@ -0,0 +1,23 @@
+package util
+
+// MergePatch applies a JSON merge patch (RFC 7396) to target. Both are decoded
+// JSON values; objects are merged key by key and null removes a key.
+func MergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = MergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
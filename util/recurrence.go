@@ -0,0 +1,81 @@
+package util
+
+import (
+	"time"
+
+	"github.com/Shubhouy1/todo-app/model"
+)
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// NextOccurrence returns the first deadline of rule strictly after from, keeping
+// the time of day. It reports false once the series has passed rule.Until.
+func NextOccurrence(rule model.Recurrence, from time.Time) (time.Time, bool) {
+	interval := rule.Interval
+	if interval <= 0 {
+		interval = 1
+	}
+
+	var next time.Time
+	switch rule.Frequency {
+	case model.FrequencyDaily:
+		next = from.AddDate(0, 0, interval)
+	case model.FrequencyWeekly:
+		next = nextWeekly(rule.Weekdays, interval, from)
+	case model.FrequencyMonthly:
+		next = nextMonthly(rule.MonthDay, interval, from)
+	default:
+		return time.Time{}, false
+	}
+
+	if rule.Until != nil && next.After(*rule.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// nextWeekly walks forward day by day to the next listed weekday, skipping the
+// weeks an interval greater than one leaves out.
+func nextWeekly(weekdays []string, interval int, from time.Time) time.Time {
+	days := make(map[time.Weekday]bool, len(weekdays))
+	for _, code := range weekdays {
+		days[weekdayCodes[code]] = true
+	}
+	if len(days) == 0 {
+		days[from.Weekday()] = true
+	}
+
+	for d := 1; d <= 7*interval+7; d++ {
+		candidate := from.AddDate(0, 0, d)
+		week := (int(from.Weekday()) + d) / 7
+		if week%interval == 0 && days[candidate.Weekday()] {
+			return candidate
+		}
+	}
+	return from.AddDate(0, 0, 7*interval)
+}
+
+// nextMonthly returns the next monthDay after from, every interval months,
+// falling back to the last day of months that are too short.
+func nextMonthly(monthDay, interval int, from time.Time) time.Time {
+	for m := 0; ; m += interval {
+		first := time.Date(from.Year(), from.Month()+time.Month(m), 1,
+			from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+		day := monthDay
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		candidate := first.AddDate(0, 0, day-1)
+		if candidate.After(from) {
+			return candidate
+		}
+	}
+}
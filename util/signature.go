@@ -0,0 +1,30 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// SignWebhook returns the HMAC-SHA256 signature of a webhook body sent at the
+// unix timestamp, in the form "sha256=<hex>". The timestamp is signed along
+// with the body, as "<timestamp>.<body>", so that a captured request cannot be
+// replayed later with a fresh timestamp.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RandomSecret returns n random bytes, hex encoded.
+func RandomSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
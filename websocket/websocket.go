@@ -0,0 +1,318 @@
+// Package websocket implements the server side of the WebSocket protocol
+// (RFC 6455) as far as the API needs it: text messages, fragmentation,
+// ping/pong and the closing handshake. Extensions and subprotocols are not
+// negotiated.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close codes sent with a close frame.
+const (
+	CloseNormal          = 1000
+	CloseProtocolError   = 1002
+	CloseUnsupported     = 1003
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+	maxControlFrameSize  = 125
+)
+
+// acceptGUID is appended to the key of the client to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrClosed is returned by ReadMessage once the peer closed the connection.
+var ErrClosed = errors.New("websocket: connection closed")
+
+// HandshakeError is returned by Upgrade when the request is not a valid
+// WebSocket handshake. Nothing has been written to the response then.
+type HandshakeError struct {
+	message string
+}
+
+func (e HandshakeError) Error() string {
+	return "websocket: " + e.message
+}
+
+// Conn is an upgraded connection. Reads must come from one goroutine, while
+// writes may come from several.
+type Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+
+	// ReadTimeout, when set, closes connections that stay silent for longer,
+	// pongs included.
+	ReadTimeout time.Duration
+	// MaxMessageSize bounds the size of a message, fragments included.
+	MaxMessageSize int64
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// Upgrade completes the handshake of a WebSocket request and takes the
+// connection over from the HTTP server.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return nil, HandshakeError{"method must be GET"}
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, HandshakeError{"not a websocket upgrade request"}
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, HandshakeError{"unsupported version, expected 13"}
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, HandshakeError{"invalid Sec-WebSocket-Key"}
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(response)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		conn:           conn,
+		br:             rw.Reader,
+		MaxMessageSize: 1 << 20,
+	}, nil
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage returns the next text or binary message, answering pings and
+// the closing handshake of the peer on the way.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var message []byte
+	started := false
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+		case opPong:
+		case opClose:
+			code := CloseNormal
+			switch {
+			case len(payload) == 1:
+				code = CloseProtocolError
+			case len(payload) >= 2:
+				code = int(binary.BigEndian.Uint16(payload))
+				if !validCloseCode(code) {
+					code = CloseProtocolError
+				}
+			}
+			c.CloseWithCode(code, "")
+			return nil, ErrClosed
+		case opText, opBinary, opContinuation:
+			if (opcode == opContinuation) != started {
+				c.CloseWithCode(CloseProtocolError, "unexpected continuation frame")
+				return nil, errors.New("websocket: unexpected continuation frame")
+			}
+			started = true
+			if c.MaxMessageSize > 0 && int64(len(message)+len(payload)) > c.MaxMessageSize {
+				c.CloseWithCode(CloseMessageTooBig, "message too big")
+				return nil, errors.New("websocket: message too big")
+			}
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			c.CloseWithCode(CloseProtocolError, "unknown opcode")
+			return nil, fmt.Errorf("websocket: unknown opcode %d", opcode)
+		}
+	}
+}
+
+// validCloseCode tells whether a peer may send code in a close frame. Codes
+// reserved for the endpoints, such as 1005 or 1006, must never be on the wire.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	if c.ReadTimeout > 0 {
+		if err := c.conn.SetReadDeadline(time.Now().Add(c.ReadTimeout)); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		c.CloseWithCode(CloseProtocolError, "reserved bits set")
+		return false, 0, nil, errors.New("websocket: reserved bits set")
+	}
+	// clients have to mask every frame they send
+	if header[1]&0x80 == 0 {
+		c.CloseWithCode(CloseProtocolError, "frame not masked")
+		return false, 0, nil, errors.New("websocket: frame not masked")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.br, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.br, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+
+	if opcode >= opClose && (length > maxControlFrameSize || !fin) {
+		c.CloseWithCode(CloseProtocolError, "invalid control frame")
+		return false, 0, nil, errors.New("websocket: invalid control frame")
+	}
+	if c.MaxMessageSize > 0 && length > uint64(c.MaxMessageSize) {
+		c.CloseWithCode(CloseMessageTooBig, "message too big")
+		return false, 0, nil, errors.New("websocket: message too big")
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteText sends data as a single text message.
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// Ping sends a ping; the pong of the peer keeps ReadTimeout from expiring.
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	header := []byte{0x80 | opcode, 0}
+	switch length := len(payload); {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	if err := c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CloseWithCode sends a close frame with code and reason and closes the connection.
+func (c *Conn) CloseWithCode(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > maxControlFrameSize-2 {
+		reason = reason[:maxControlFrameSize-2]
+	}
+	// the close frame is best effort, the peer may already be gone
+	_ = c.writeFrame(opClose, append(payload, reason...))
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.conn.Close()
+}
+
+// Close closes the connection normally.
+func (c *Conn) Close() error {
+	return c.CloseWithCode(CloseNormal, "")
+}
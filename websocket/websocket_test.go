@@ -0,0 +1,484 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// clientFrame encodes a frame the way a client sends it, masked unless told otherwise.
+func clientFrame(fin bool, opcode byte, payload []byte, masked bool) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first}
+
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+	switch length := len(payload); {
+	case length <= 125:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	if !masked {
+		return append(frame, payload...)
+	}
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+func text(fin bool, payload string) []byte {
+	return clientFrame(fin, opText, []byte(payload), true)
+}
+
+func continuation(fin bool, payload string) []byte {
+	return clientFrame(fin, opContinuation, []byte(payload), true)
+}
+
+func closeFrame(payload []byte) []byte {
+	return clientFrame(true, opClose, payload, true)
+}
+
+func closeCode(code int) []byte {
+	return closeFrame(binary.BigEndian.AppendUint16(nil, uint16(code)))
+}
+
+func frames(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// serverFrames decodes what the server wrote into one line per frame, such as
+// "pong:abc" or "close:1002", failing on masked frames.
+func serverFrames(t *testing.T, data []byte) []string {
+	t.Helper()
+
+	var out []string
+	for len(data) > 0 {
+		if len(data) < 2 {
+			t.Fatalf("truncated frame header %x", data)
+		}
+		if data[0]&0x80 == 0 {
+			t.Errorf("server frame without FIN")
+		}
+		if data[1]&0x80 != 0 {
+			t.Errorf("server frame is masked")
+		}
+		opcode := data[0] & 0x0F
+		length := uint64(data[1] & 0x7F)
+		data = data[2:]
+		switch length {
+		case 126:
+			length = uint64(binary.BigEndian.Uint16(data))
+			data = data[2:]
+		case 127:
+			length = binary.BigEndian.Uint64(data)
+			data = data[8:]
+		}
+		if uint64(len(data)) < length {
+			t.Fatalf("truncated frame payload")
+		}
+		payload := data[:length]
+		data = data[length:]
+
+		switch opcode {
+		case opClose:
+			out = append(out, fmt.Sprintf("close:%d", binary.BigEndian.Uint16(payload)))
+		case opPong:
+			out = append(out, "pong:"+string(payload))
+		case opPing:
+			out = append(out, "ping:"+string(payload))
+		case opText:
+			out = append(out, "text:"+string(payload))
+		default:
+			t.Fatalf("unexpected opcode %d", opcode)
+		}
+	}
+	return out
+}
+
+// readMessage feeds input to a server connection, reads one message and
+// returns it with the frames the server wrote back.
+func readMessage(t *testing.T, input []byte, maxMessageSize int64) ([]byte, []string, error) {
+	t.Helper()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	conn := &Conn{conn: server, br: bufio.NewReader(server), MaxMessageSize: maxMessageSize}
+
+	// the pipe is unbuffered, so the client writes and reads on its own
+	go client.Write(input)
+	written := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(client)
+		written <- data
+	}()
+
+	message, err := conn.ReadMessage()
+	server.Close()
+	return message, serverFrames(t, <-written), err
+}
+
+func TestReadMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		max     int64
+		want    string
+		wantErr error
+		written []string
+	}{
+		{
+			name:  "masked text frame",
+			input: text(true, "hello"),
+			want:  "hello",
+		},
+		{
+			name:  "masked binary frame",
+			input: clientFrame(true, opBinary, []byte{0, 1, 2}, true),
+			want:  "\x00\x01\x02",
+		},
+		{
+			name:  "empty text frame",
+			input: text(true, ""),
+			want:  "",
+		},
+		{
+			name:    "unmasked frame",
+			input:   clientFrame(true, opText, []byte("hello"), false),
+			wantErr: errors.New("websocket: frame not masked"),
+			written: []string{"close:1002"},
+		},
+		{
+			name:  "16-bit length",
+			input: text(true, strings.Repeat("a", 300)),
+			want:  strings.Repeat("a", 300),
+		},
+		{
+			name:  "64-bit length",
+			input: text(true, strings.Repeat("a", 70000)),
+			want:  strings.Repeat("a", 70000),
+		},
+		{
+			name:  "fragmented message",
+			input: frames(text(false, "hel"), continuation(false, "lo "), continuation(true, "world")),
+			want:  "hello world",
+		},
+		{
+			name:    "fragmented message with ping in between",
+			input:   frames(text(false, "hel"), clientFrame(true, opPing, []byte("abc"), true), continuation(true, "lo")),
+			want:    "hello",
+			written: []string{"pong:abc"},
+		},
+		{
+			name:    "continuation without a message",
+			input:   continuation(true, "lo"),
+			wantErr: errors.New("websocket: unexpected continuation frame"),
+			written: []string{"close:1002"},
+		},
+		{
+			name:    "new message inside a fragmented one",
+			input:   frames(text(false, "hel"), text(true, "lo")),
+			wantErr: errors.New("websocket: unexpected continuation frame"),
+			written: []string{"close:1002"},
+		},
+		{
+			name:    "oversize frame",
+			input:   text(true, "hello world"),
+			max:     10,
+			wantErr: errors.New("websocket: message too big"),
+			written: []string{"close:1009"},
+		},
+		{
+			name:    "oversize fragmented message",
+			input:   frames(text(false, "hello "), continuation(true, "world")),
+			max:     10,
+			wantErr: errors.New("websocket: message too big"),
+			written: []string{"close:1009"},
+		},
+		{
+			name: "oversize length is rejected before the payload",
+			input: []byte{0x81, 0x80 | 127, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+				0x12, 0x34, 0x56, 0x78},
+			max:     1 << 20,
+			wantErr: errors.New("websocket: message too big"),
+			written: []string{"close:1009"},
+		},
+		{
+			name:  "message at the size limit",
+			input: frames(text(false, "hello"), continuation(true, "world")),
+			max:   10,
+			want:  "helloworld",
+		},
+		{
+			name:    "ping is answered with its payload",
+			input:   frames(clientFrame(true, opPing, []byte("abc"), true), text(true, "hello")),
+			want:    "hello",
+			written: []string{"pong:abc"},
+		},
+		{
+			name:  "pong is ignored",
+			input: frames(clientFrame(true, opPong, []byte("abc"), true), text(true, "hello")),
+			want:  "hello",
+		},
+		{
+			name:    "control frame over 125 bytes",
+			input:   clientFrame(true, opPing, bytes.Repeat([]byte("a"), 126), true),
+			wantErr: errors.New("websocket: invalid control frame"),
+			written: []string{"close:1002"},
+		},
+		{
+			name:    "fragmented control frame",
+			input:   clientFrame(false, opPing, []byte("abc"), true),
+			wantErr: errors.New("websocket: invalid control frame"),
+			written: []string{"close:1002"},
+		},
+		{
+			name: "reserved bits",
+			input: func() []byte {
+				frame := text(true, "hello")
+				frame[0] |= 0x40
+				return frame
+			}(),
+			wantErr: errors.New("websocket: reserved bits set"),
+			written: []string{"close:1002"},
+		},
+		{
+			name:    "unknown opcode",
+			input:   clientFrame(true, 0x3, []byte("hello"), true),
+			wantErr: errors.New("websocket: unknown opcode 3"),
+			written: []string{"close:1002"},
+		},
+		{
+			name:    "close without code",
+			input:   closeFrame(nil),
+			wantErr: ErrClosed,
+			written: []string{"close:1000"},
+		},
+		{
+			name:    "close with reason",
+			input:   closeFrame(append(binary.BigEndian.AppendUint16(nil, 1001), "bye"...)),
+			wantErr: ErrClosed,
+			written: []string{"close:1001"},
+		},
+		{
+			name:    "close with one byte",
+			input:   closeFrame([]byte{0x03}),
+			wantErr: ErrClosed,
+			written: []string{"close:1002"},
+		},
+		{
+			name:    "close during a fragmented message",
+			input:   frames(text(false, "hel"), closeCode(CloseNormal)),
+			wantErr: ErrClosed,
+			written: []string{"close:1000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, written, err := readMessage(t, tt.input, tt.max)
+
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("ReadMessage() error = %v", err)
+			case tt.wantErr == ErrClosed && !errors.Is(err, ErrClosed):
+				t.Fatalf("ReadMessage() error = %v, want %v", err, ErrClosed)
+			case tt.wantErr != nil && (err == nil || err.Error() != tt.wantErr.Error()):
+				t.Fatalf("ReadMessage() error = %v, want %v", err, tt.wantErr)
+			case tt.wantErr == nil && string(message) != tt.want:
+				t.Errorf("ReadMessage() = %q, want %q", message, tt.want)
+			}
+			if strings.Join(written, " ") != strings.Join(tt.written, " ") {
+				t.Errorf("server wrote %v, want %v", written, tt.written)
+			}
+		})
+	}
+}
+
+func TestCloseCodes(t *testing.T) {
+	tests := []struct {
+		code int
+		want int
+	}{
+		{1000, 1000},
+		{1001, 1001},
+		{1002, 1002},
+		{1003, 1003},
+		{1004, 1002},
+		{1005, 1002},
+		{1006, 1002},
+		{1007, 1007},
+		{1011, 1011},
+		{1014, 1014},
+		{1015, 1002},
+		{999, 1002},
+		{2999, 1002},
+		{3000, 3000},
+		{4999, 4999},
+		{5000, 1002},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.code), func(t *testing.T) {
+			_, written, err := readMessage(t, closeCode(tt.code), 0)
+			if !errors.Is(err, ErrClosed) {
+				t.Fatalf("ReadMessage() error = %v, want %v", err, ErrClosed)
+			}
+			want := fmt.Sprintf("close:%d", tt.want)
+			if len(written) != 1 || written[0] != want {
+				t.Errorf("server wrote %v, want [%s]", written, want)
+			}
+		})
+	}
+}
+
+// writeFrames runs write against a server connection and returns the raw
+// bytes it sent.
+func writeFrames(t *testing.T, write func(c *Conn)) []byte {
+	t.Helper()
+
+	server, client := net.Pipe()
+	defer client.Close()
+	conn := &Conn{conn: server, br: bufio.NewReader(server)}
+
+	written := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(client)
+		written <- data
+	}()
+
+	write(conn)
+	server.Close()
+	return <-written
+}
+
+func TestWriteFrames(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		headerSize int
+	}{
+		{"7-bit length", 125, 2},
+		{"16-bit length", 126, 4},
+		{"16-bit length upper bound", 0xFFFF, 4},
+		{"64-bit length", 0x10000, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := strings.Repeat("a", tt.size)
+			data := writeFrames(t, func(c *Conn) {
+				if err := c.WriteText([]byte(payload)); err != nil {
+					t.Errorf("WriteText() error = %v", err)
+				}
+			})
+
+			if len(data) != tt.headerSize+tt.size {
+				t.Fatalf("wrote %d bytes, want %d", len(data), tt.headerSize+tt.size)
+			}
+			written := serverFrames(t, data)
+			if len(written) != 1 || written[0] != "text:"+payload {
+				t.Errorf("server wrote %d frames, want one text frame", len(written))
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	data := writeFrames(t, func(c *Conn) {
+		if err := c.Ping(); err != nil {
+			t.Errorf("Ping() error = %v", err)
+		}
+	})
+
+	if written := serverFrames(t, data); len(written) != 1 || written[0] != "ping:" {
+		t.Errorf("server wrote %v, want [ping:]", written)
+	}
+}
+
+func TestCloseWithCode(t *testing.T) {
+	var afterClose error
+	data := writeFrames(t, func(c *Conn) {
+		if err := c.CloseWithCode(ClosePolicyViolation, strings.Repeat("r", 200)); err != nil {
+			t.Errorf("CloseWithCode() error = %v", err)
+		}
+		if err := c.Close(); err != nil {
+			t.Errorf("second Close() error = %v", err)
+		}
+		afterClose = c.WriteText([]byte("hello"))
+	})
+
+	if !errors.Is(afterClose, ErrClosed) {
+		t.Errorf("WriteText() after close error = %v, want %v", afterClose, ErrClosed)
+	}
+	if len(data) != 2+maxControlFrameSize {
+		t.Fatalf("close frame is %d bytes, want the reason cut to fit %d", len(data), maxControlFrameSize)
+	}
+	if written := serverFrames(t, data); len(written) != 1 || written[0] != "close:1008" {
+		t.Errorf("server wrote %v, want [close:1008]", written)
+	}
+}
+
+func TestUpgradeHandshakeErrors(t *testing.T) {
+	valid := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		r.Header.Set("Connection", "keep-alive, Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *http.Request)
+		want   string
+	}{
+		{"method", func(r *http.Request) { r.Method = http.MethodPost }, "websocket: method must be GET"},
+		{"connection", func(r *http.Request) { r.Header.Del("Connection") }, "websocket: not a websocket upgrade request"},
+		{"upgrade", func(r *http.Request) { r.Header.Set("Upgrade", "h2c") }, "websocket: not a websocket upgrade request"},
+		{"version", func(r *http.Request) { r.Header.Set("Sec-WebSocket-Version", "8") }, "websocket: unsupported version, expected 13"},
+		{"key", func(r *http.Request) { r.Header.Set("Sec-WebSocket-Key", "c2hvcnQ=") }, "websocket: invalid Sec-WebSocket-Key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(r)
+
+			_, err := Upgrade(httptest.NewRecorder(), r)
+			var handshakeErr HandshakeError
+			if !errors.As(err, &handshakeErr) || err.Error() != tt.want {
+				t.Errorf("Upgrade() error = %v, want HandshakeError %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// the example of RFC 6455, section 1.3
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("acceptKey() = %q", got)
+	}
+}